@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go-logstash-exporter/pkg/collector"
+	"go-logstash-exporter/pkg/naming"
+	"go-logstash-exporter/pkg/server"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCheckConfigCommand 创建 check-config 子命令：校验配置文件，有错误时以非零状态退出
+func NewCheckConfigCommand() *cobra.Command {
+	var probe bool
+
+	c := &cobra.Command{
+		Use:   "check-config",
+		Short: "校验配置文件",
+		Long:  "校验配置文件的语法、未知配置项和各项取值，可选地测试与每个端点的连通性。存在错误时以非零状态退出。",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			configFile = configLocation()
+			if configFile == "" {
+				fmt.Fprintf(os.Stderr, "错误: 必须通过 --config.file 或环境变量 %s 指定配置文件\n", configFileEnv)
+				os.Exit(1)
+			}
+
+			errs := checkConfig(cmd, configFile, probe)
+			for _, err := range errs {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", configFile, err)
+			}
+			if len(errs) > 0 {
+				fmt.Fprintf(os.Stderr, "配置文件无效: 发现 %d 个错误\n", len(errs))
+				os.Exit(1)
+			}
+			fmt.Printf("配置文件有效: %s\n", configFile)
+		},
+	}
+
+	c.Flags().BoolVar(&probe, "probe", false, "请求每个端点的 /_node，测试连通性")
+	RegisterFlags(c.Flags())
+	return c
+}
+
+// checkConfig 校验配置文件，返回按行号排序的错误
+func checkConfig(cmd *cobra.Command, filename string, probe bool) []*server.ConfigError {
+	doc, err := server.ParseConfigDocument(filename)
+	if err != nil {
+		var configErr *server.ConfigError
+		if errors.As(err, &configErr) {
+			return []*server.ConfigError{configErr}
+		}
+		return []*server.ConfigError{{Err: err}}
+	}
+
+	// 未知的配置项和类型错误；只校验配置文件本身，不合并 LOGSTASH_EXPORTER_* 环境变量
+	errs := doc.UnknownKeys()
+	config, err := server.LoadConfigFile(filename)
+	if err != nil {
+		errs = append(errs, server.DecodeErrors(err)...)
+		doc.Locate(errs)
+		return errs
+	}
+
+	// 配置项取值
+	errs = append(errs, server.ValidateConfig(config)...)
+
+	for i := range config.MetricRelabelConfigs {
+		if _, err := relabelRules(config.MetricRelabelConfigs[i : i+1]); err != nil {
+			errs = append(errs, &server.ConfigError{
+				Path: fmt.Sprintf("metric_relabel_configs[%d]", i),
+				Err:  errors.New(strings.TrimPrefix(err.Error(), "metric_relabel_configs[0]: ")),
+			})
+		}
+	}
+
+	if _, err := naming.Lookup(config.Naming.Profile); err != nil {
+		errs = append(errs, &server.ConfigError{Path: "naming.profile", Err: err})
+	}
+
+	// 证书内容在创建客户端时校验，文件不存在的已经报告过
+	opts := collectorOptions(config)
+	globalCollectors := collectorSelection(cmd.Flags(), config.Collectors)
+	checkClient := func(path string, opts collector.Options) {
+		if hasErrorUnder(errs, path) {
+			return
+		}
+		if _, err := collector.NewAPIClient("", opts); err != nil {
+			errs = append(errs, &server.ConfigError{Path: path, Err: err})
+		}
+	}
+	checkClient("tls", opts)
+	labels := endpointLabels(config.Labels, config.Endpoints)
+	endpoints := make([]collector.Options, len(config.Endpoints))
+	for i, ep := range config.Endpoints {
+		endpoints[i] = endpointOptions(opts, globalCollectors, labels[i], ep)
+		if ep.TLS != (server.TLSConfig{}) {
+			checkClient(fmt.Sprintf("endpoints[%d].tls", i), endpoints[i])
+		}
+	}
+	for name, module := range config.Modules {
+		if module.TLS != (server.TLSConfig{}) {
+			checkClient("modules."+name+".tls", moduleOptions(opts, globalCollectors, module))
+		}
+	}
+
+	// 连通性测试只在配置有效时进行
+	if probe && len(errs) == 0 {
+		errs = append(errs, probeEndpoints(config, endpoints)...)
+	}
+
+	doc.Locate(errs)
+	return errs
+}
+
+// probeEndpoints 使用每个端点的参数请求 /_node，返回无法访问的端点
+func probeEndpoints(config *server.LogstashConfig, endpoints []collector.Options) []*server.ConfigError {
+	var errs []*server.ConfigError
+	for i, ep := range config.Endpoints {
+		endpoint := strings.TrimSpace(ep.URL)
+		path := fmt.Sprintf("endpoints[%d].url", i)
+
+		// 连通性测试不重试，尽快报告结果
+		opts := endpoints[i]
+		opts.Retry.MaxAttempts = 1
+
+		client, err := collector.NewAPIClient(endpoint, opts)
+		if err == nil {
+			var info collector.NodeInfoResponse
+			if info, err = client.NodeInfo(); err == nil {
+				fmt.Printf("%s 可访问: Logstash %s（%s）\n", endpoint, info.Version, info.Name)
+				continue
+			}
+		}
+		errs = append(errs, &server.ConfigError{Path: path, Err: fmt.Errorf("无法访问 %s: %v", endpoint, err)})
+	}
+	return errs
+}
+
+// hasErrorUnder 判断 path 或其下级配置项是否已经有错误
+func hasErrorUnder(errs []*server.ConfigError, path string) bool {
+	for _, err := range errs {
+		if err.Path == path || strings.HasPrefix(err.Path, path+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"go-logstash-exporter/pkg/naming"
+	"go-logstash-exporter/pkg/server"
+
+	"github.com/spf13/cobra"
+)
+
+// NewConfigCommand 创建 config 子命令，config schema 输出配置文件的 JSON Schema
+func NewConfigCommand() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "config",
+		Short: "配置文件相关工具",
+	}
+
+	c.AddCommand(&cobra.Command{
+		Use:   "schema",
+		Short: "输出配置文件的 JSON Schema",
+		Long:  "输出配置文件的 JSON Schema（draft-07），可供编辑器校验和补全 YAML 配置文件。",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			schema, err := server.ConfigSchemaJSON(naming.Names())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "生成 JSON Schema 失败: %v\n", err)
+				os.Exit(1)
+			}
+			cmd.OutOrStdout().Write(schema)
+		},
+	})
+	return c
+}
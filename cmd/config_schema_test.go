@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// 仓库中的 config.schema.json 必须与 config schema 的输出一致
+// 修改配置结构后使用 go run . config schema > config.schema.json 重新生成
+func TestConfigSchemaUpToDate(t *testing.T) {
+	var out bytes.Buffer
+	c := NewConfigCommand()
+	c.SetOut(&out)
+	c.SetArgs([]string{"schema"})
+	if err := c.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	committed, err := os.ReadFile("../config.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), committed) {
+		t.Error("config.schema.json 已过期，请运行 go run . config schema > config.schema.json 重新生成")
+	}
+}
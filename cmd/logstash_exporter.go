@@ -2,66 +2,104 @@ package cmd
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"go-logstash-exporter/pkg/collector"
+	"go-logstash-exporter/pkg/relabel"
 	"go-logstash-exporter/pkg/server"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
-	configFile string // 配置文件路径
+	configFile      string // 配置文件路径
+	enableLifecycle bool   // 是否提供 POST /-/reload 接口
 )
 
+// 指定配置文件路径的环境变量，优先级低于 --config.file
+// EXPORTER_CONFIG_LOCATION 由 Dockerfile 设置，LOGSTASH_EXPORTER_CONFIG_FILE 优先于它
+const (
+	configFileEnv     = server.EnvPrefix + "_CONFIG_FILE"
+	configLocationEnv = "EXPORTER_CONFIG_LOCATION"
+)
+
+// configLocation 返回配置文件路径：--config.file、LOGSTASH_EXPORTER_CONFIG_FILE、EXPORTER_CONFIG_LOCATION 依次生效
+// 都未设置时返回空字符串，只使用环境变量和默认值
+func configLocation() string {
+	if configFile != "" {
+		return configFile
+	}
+	if location := os.Getenv(configFileEnv); location != "" {
+		return location
+	}
+	return os.Getenv(configLocationEnv)
+}
+
 // Run 是程序的主入口函数
 func Run(cmd *cobra.Command, args []string) {
-	// 必须指定配置文件
-	if configFile == "" {
-		fmt.Fprintf(os.Stderr, "错误: 必须通过 --config.file 指定配置文件\n")
-		os.Exit(1)
-	}
+	// 重新加载时使用同一个配置文件
+	configFile = configLocation()
 
-	var endpoints []string
-	var bindAddress string = ":8080" // 默认监听地址，当配置文件中未指定时使用
+	var bindAddress string = ":8080" // 默认监听地址，当配置中未指定时使用
 
-	// 从配置文件读取
+	// 读取配置：环境变量覆盖配置文件，命令行参数在创建收集器时覆盖两者
 	config, err := server.LoadConfig(configFile)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "加载配置文件失败: %v\n", err)
+		fmt.Fprintf(os.Stderr, "加载配置失败: %v\n", err)
 		os.Exit(1)
 	}
-	endpoints = config.Endpoints
 	if config.Web.ListenAddress != "" {
 		bindAddress = config.Web.ListenAddress
 	}
 
+	// 严格模式使用 pedantic 注册表，额外检查收集到的指标是否与 Describe 一致
+	registry := prometheus.NewRegistry()
+	if config.Strict {
+		registry = prometheus.NewPedanticRegistry()
+	}
+
 	// 注册系统信息收集器
-	prometheus.MustRegister(collectors.NewBuildInfoCollector())
+	registry.MustRegister(
+		collectors.NewBuildInfoCollector(),
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
 
-	// 为每个 endpoint 创建一个收集器
-	for _, endpoint := range endpoints {
-		endpoint = strings.TrimSpace(endpoint)
-		if endpoint == "" {
-			continue
-		}
+	// 所有端点（包括 /probe）共享的工作池，限制同时请求 Logstash 的子收集器数量
+	// 工作池的大小不随重新加载变化
+	pool := collector.NewWorkerPool(config.MaxConcurrency)
+	if pool != nil {
+		registry.MustRegister(pool)
+	}
 
-		// 创建并注册 Logstash 收集器
-		logstashCollector, err := collector.New(endpoint)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "创建收集器失败 [%s]: %v\n", endpoint, err)
-			continue
-		}
-		prometheus.MustRegister(logstashCollector)
-		fmt.Printf("添加 Logstash 实例: %s\n", endpoint)
+	srv := server.New(bindAddress, registry)
+	e := newExporter(cmd.Flags(), registry, srv, pool, config)
+
+	// 重新标记、命名、模块等配置错误直接退出
+	p, err := e.plan(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if err := e.apply(p, true); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
 	}
 
-	// 创建并启动 HTTP 服务器
-	srv := server.New(bindAddress)
+	// 创建并启动 HTTP 服务器；/-/reload 允许任何能访问监听地址的客户端重新加载配置，需要显式开启
+	if enableLifecycle {
+		srv.SetReload(e.reload)
+	}
 	srv.SetupRoutes()
+	e.watch(config.WatchConfig)
 
 	fmt.Printf("启动 Logstash 指标采集器，监听地址: %s\n", bindAddress)
 	if err := srv.Start(); err != nil {
@@ -69,3 +107,331 @@ func Run(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 }
+
+// RegisterFlags 注册配置文件路径参数 --config.file、--web.enable-lifecycle 和子收集器开关参数 --collectors.<name>
+func RegisterFlags(flags *pflag.FlagSet) {
+	flags.StringVar(&configFile, "config.file", "", fmt.Sprintf("配置文件路径（未设置时依次读取环境变量 %s、%s）", configFileEnv, configLocationEnv))
+	flags.BoolVar(&enableLifecycle, "web.enable-lifecycle", false, "提供 POST /-/reload 接口重新加载配置")
+	defaults := collector.DefaultCollectors()
+	for _, name := range collector.CollectorNames() {
+		flags.Bool("collectors."+name, defaults[name], fmt.Sprintf("启用 %s 子收集器（覆盖配置文件中的全局开关）", name))
+	}
+}
+
+// collectorSelection 返回全局子收集器开关，只有显式设置的命令行参数才会覆盖配置文件
+func collectorSelection(flags *pflag.FlagSet, configured map[string]bool) map[string]bool {
+	selection := mergeSelection(configured, nil)
+	for _, name := range collector.CollectorNames() {
+		flag := "collectors." + name
+		if !flags.Changed(flag) {
+			continue
+		}
+		if on, err := flags.GetBool(flag); err == nil {
+			selection[name] = on
+		}
+	}
+	return selection
+}
+
+// mergeSelection 返回 base 被 override 覆盖后的子收集器开关副本
+func mergeSelection(base, override map[string]bool) map[string]bool {
+	merged := make(map[string]bool, len(base)+len(override))
+	for name, on := range base {
+		merged[name] = on
+	}
+	for name, on := range override {
+		merged[name] = on
+	}
+	return merged
+}
+
+// endpointLabels 返回每个端点的常量标签：端点标签覆盖全局默认标签
+// 注册表要求同名指标的标签名一致，因此所有端点都补齐为相同的标签名集合
+func endpointLabels(defaults map[string]string, endpoints []server.EndpointConfig) []map[string]string {
+	names := map[string]bool{}
+	for name := range defaults {
+		names[name] = true
+	}
+	for _, ep := range endpoints {
+		for name := range ep.Labels {
+			names[name] = true
+		}
+	}
+
+	labels := make([]map[string]string, len(endpoints))
+	for i, ep := range endpoints {
+		merged := make(map[string]string, len(names))
+		for name := range names {
+			merged[name] = ""
+		}
+		for name, value := range defaults {
+			merged[name] = value
+		}
+		for name, value := range ep.Labels {
+			merged[name] = value
+		}
+		labels[i] = merged
+	}
+	return labels
+}
+
+// collectorOptions 将配置文件中的参数转换为收集器参数
+func collectorOptions(config *server.LogstashConfig) collector.Options {
+	return collector.Options{
+		Timeout: config.Timeout,
+		Retry: collector.RetryPolicy{
+			MaxAttempts:    config.Retry.MaxAttempts,
+			InitialBackoff: config.Retry.InitialBackoff,
+			MaxBackoff:     config.Retry.MaxBackoff,
+		},
+		Breaker: collector.BreakerConfig{
+			FailureThreshold: config.CircuitBreaker.FailureThreshold,
+			OpenTimeout:      config.CircuitBreaker.OpenTimeout,
+		},
+		MaxBodySize: config.MaxBodySize,
+		NodeInfoTTL: config.NodeInfoTTL,
+		Transport: collector.TransportConfig{
+			Mode:        collector.TransportMode(config.Transport.Mode),
+			FixturesDir: config.Transport.FixturesDir,
+		},
+		Strict:             config.Strict,
+		MaxSeriesPerMetric: config.MaxSeriesPerMetric,
+		Poll: collector.PollConfig{
+			Interval: config.Poll.Interval,
+			MaxAge:   config.Poll.MaxAge,
+		},
+		MinRefreshInterval: config.MinRefreshInterval,
+		Labels:             config.Labels,
+		InstanceLabel:      config.InstanceLabel,
+		Auth:               authConfig(config.Auth),
+		TLS:                tlsConfig(config.TLS),
+	}
+}
+
+// endpointOptions 在全局收集器参数的基础上应用端点配置，labels 是补齐后的端点常量标签
+func endpointOptions(opts collector.Options, globalCollectors map[string]bool, labels map[string]string, ep server.EndpointConfig) collector.Options {
+	// 端点级子收集器开关覆盖全局开关
+	opts.Name = strings.TrimSpace(ep.Name)
+	opts.Collectors = mergeSelection(globalCollectors, ep.Collectors)
+	opts.Filters = filterConfig(ep.Filters)
+	opts.Labels = labels
+	if ep.Timeout > 0 {
+		opts.Timeout = ep.Timeout
+	}
+	if ep.Auth != (server.AuthConfig{}) {
+		opts.Auth = authConfig(ep.Auth)
+	}
+	if ep.TLS != (server.TLSConfig{}) {
+		opts.TLS = tlsConfig(ep.TLS)
+	}
+	if ep.PollInterval > 0 {
+		opts.Poll.Interval = ep.PollInterval
+	}
+	return opts
+}
+
+// authConfig 将配置文件中的认证信息转换为收集器参数
+func authConfig(auth server.AuthConfig) collector.AuthConfig {
+	return collector.AuthConfig{
+		Username:    auth.Username,
+		Password:    auth.Password,
+		BearerToken: auth.BearerToken,
+	}
+}
+
+// tlsConfig 将配置文件中的 TLS 参数转换为收集器参数
+func tlsConfig(tls server.TLSConfig) collector.TLSConfig {
+	return collector.TLSConfig{
+		CAFile:             tls.CAFile,
+		CertFile:           tls.CertFile,
+		KeyFile:            tls.KeyFile,
+		ServerName:         tls.ServerName,
+		InsecureSkipVerify: tls.InsecureSkipVerify,
+	}
+}
+
+// relabelRules 编译配置中的 metric_relabel_configs
+func relabelRules(configs []server.RelabelConfig) (relabel.Rules, error) {
+	converted := make([]relabel.Config, len(configs))
+	for i, c := range configs {
+		converted[i] = relabel.Config{
+			SourceLabels: c.SourceLabels,
+			Separator:    c.Separator,
+			Regex:        c.Regex,
+			Modulus:      c.Modulus,
+			TargetLabel:  c.TargetLabel,
+			Replacement:  c.Replacement,
+			Action:       relabel.Action(c.Action),
+		}
+	}
+	return relabel.Compile(converted)
+}
+
+// driftFunc 返回 /drift 使用的报告生成函数，未指定集群时按名称顺序返回所有集群的报告
+func driftFunc(clusters map[string]*collector.ClusterCollector) server.DriftFunc {
+	return func(name string) (interface{}, bool) {
+		if name != "" {
+			cluster, ok := clusters[name]
+			if !ok {
+				return nil, false
+			}
+			return cluster.DriftReport(), true
+		}
+
+		names := make([]string, 0, len(clusters))
+		for name := range clusters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		reports := make([]collector.DriftReport, 0, len(names))
+		for _, name := range names {
+			reports = append(reports, clusters[name].DriftReport())
+		}
+		return reports, true
+	}
+}
+
+// filterConfig 将端点的过滤配置转换为收集器的过滤规则
+func filterConfig(filters server.FiltersConfig) collector.FilterConfig {
+	match := func(m server.MatchConfig) collector.MatchConfig {
+		return collector.MatchConfig{Include: m.Include, Exclude: m.Exclude}
+	}
+
+	return collector.FilterConfig{
+		Pipelines:       match(filters.Pipelines),
+		PluginTypes:     match(filters.PluginTypes),
+		PluginNames:     match(filters.PluginNames),
+		PluginIDs:       match(filters.PluginIDs),
+		CollapsePlugins: filters.CollapsePlugins,
+	}
+}
+
+// probeIdleTimeout 是 /probe 目标熔断器的保留时间，超过该时间未被探测的目标不再保留熔断状态
+const probeIdleTimeout = 10 * time.Minute
+
+// prober 为 /probe 创建收集器：同一模块的所有目标共享一个传输，同一模块和目标的探测共享一个熔断器
+// 未配置 default 模块时 default 使用全局配置
+type prober struct {
+	modules map[string]collector.Options // 按模块名保存的收集器参数，已设置共享的传输
+
+	mu       sync.Mutex
+	breakers map[probeKey]*probeBreaker
+}
+
+// probeKey 标识一个探测目标
+type probeKey struct {
+	module string
+	target string
+}
+
+// probeBreaker 是探测目标的熔断器及最近一次使用的时间
+type probeBreaker struct {
+	breaker  *collector.CircuitBreaker
+	lastUsed time.Time
+}
+
+// newProber 为每个模块创建传输，证书等配置错误在加载配置时就会暴露
+func newProber(modules map[string]server.ModuleConfig, opts collector.Options, globalCollectors map[string]bool) (*prober, error) {
+	p := &prober{
+		modules:  map[string]collector.Options{},
+		breakers: map[probeKey]*probeBreaker{},
+	}
+
+	names := map[string]server.ModuleConfig{"default": {}}
+	for name, module := range modules {
+		names[name] = module
+	}
+	for name, module := range names {
+		moduleOpts := moduleOptions(opts, globalCollectors, module)
+		transport, err := collector.NewHTTPTransport(moduleOpts.Auth, moduleOpts.TLS)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("模块 %s 配置无效: %v", name, err)
+		}
+		moduleOpts.HTTPTransport = transport
+		p.modules[name] = moduleOpts
+	}
+	return p, nil
+}
+
+// probe 实现 server.ProbeFunc，为目标创建复用模块传输和目标熔断器的收集器
+func (p *prober) probe(target, module string) (server.ProbeCollector, error) {
+	opts, ok := p.modules[module]
+	if !ok {
+		return nil, fmt.Errorf("未知的模块: %s", module)
+	}
+
+	endpoint, err := probeTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.CircuitBreaker = p.breaker(probeKey{module: module, target: endpoint}, opts.Breaker)
+	return collector.NewWithOptions(endpoint, opts)
+}
+
+// breaker 返回探测目标的熔断器，同时清理长时间未被探测的目标
+func (p *prober) breaker(key probeKey, config collector.BreakerConfig) *collector.CircuitBreaker {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	for k, b := range p.breakers {
+		if now.Sub(b.lastUsed) > probeIdleTimeout {
+			delete(p.breakers, k)
+		}
+	}
+
+	b, ok := p.breakers[key]
+	if !ok {
+		b = &probeBreaker{breaker: collector.NewCircuitBreaker(config)}
+		p.breakers[key] = b
+	}
+	b.lastUsed = now
+	return b.breaker
+}
+
+// Close 释放所有模块传输的空闲连接
+func (p *prober) Close() {
+	for _, opts := range p.modules {
+		collector.CloseTransport(opts.HTTPTransport)
+	}
+}
+
+// moduleOptions 在全局收集器参数的基础上应用模块配置
+// 探测总是同步请求目标，不使用后台轮询
+func moduleOptions(opts collector.Options, globalCollectors map[string]bool, module server.ModuleConfig) collector.Options {
+	opts.Poll = collector.PollConfig{}
+	if module.Timeout > 0 {
+		opts.Timeout = module.Timeout
+	}
+	if module.Auth != (server.AuthConfig{}) {
+		opts.Auth = authConfig(module.Auth)
+	}
+	if module.TLS != (server.TLSConfig{}) {
+		opts.TLS = tlsConfig(module.TLS)
+	}
+	opts.Collectors = mergeSelection(globalCollectors, module.Collectors)
+	opts.Filters = filterConfig(module.Filters)
+	return opts
+}
+
+// probeTarget 将探测目标规范化为 Logstash API 地址，省略协议时使用 http
+func probeTarget(target string) (string, error) {
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("无效的目标地址: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("不支持的协议: %s", u.Scheme)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("目标地址缺少主机: %s", target)
+	}
+	return strings.TrimSuffix(u.String(), "/"), nil
+}
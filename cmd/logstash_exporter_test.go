@@ -0,0 +1,184 @@
+package cmd
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go-logstash-exporter/pkg/collector"
+	"go-logstash-exporter/pkg/logstashtest"
+	"go-logstash-exporter/pkg/server"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/pflag"
+)
+
+// 同一模块的探测共享传输，同一目标的探测共享熔断器
+func TestProberReusesTransportAndBreaker(t *testing.T) {
+	target := logstashtest.NewTestServer(t, logstashtest.Version8,
+		logstashtest.WithFault(logstashtest.Fault{StatusCode: http.StatusServiceUnavailable}))
+
+	opts := collector.DefaultOptions()
+	opts.Retry.MaxAttempts = 1
+	opts.Breaker.FailureThreshold = 1
+
+	p, err := newProber(map[string]server.ModuleConfig{"secure": {}}, opts, map[string]bool{"node_stats": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	if p.modules["default"].HTTPTransport == nil || p.modules["secure"].HTTPTransport == nil {
+		t.Fatal("模块没有共享的传输")
+	}
+
+	probe := func(module string) {
+		c, err := p.probe(strings.TrimPrefix(target.URL, "http://"), module)
+		if err != nil {
+			t.Fatal(err)
+		}
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(c)
+		registry.Gather()
+	}
+
+	// 第一次探测失败后熔断器打开，之后的探测不再请求目标
+	probe("default")
+	requests := target.Requests(logstashtest.PathNodeStats)
+	if requests == 0 {
+		t.Fatal("第一次探测没有请求目标")
+	}
+	probe("default")
+	if got := target.Requests(logstashtest.PathNodeStats); got != requests {
+		t.Errorf("熔断器打开后仍然请求了目标: %d 次，之前 %d 次", got, requests)
+	}
+
+	// 其他模块使用独立的熔断器
+	probe("secure")
+	if got := target.Requests(logstashtest.PathNodeStats); got == requests {
+		t.Errorf("secure 模块共享了 default 模块的熔断器")
+	}
+
+	if _, err := p.probe(target.URL, "missing"); err == nil {
+		t.Error("未知模块没有返回错误")
+	}
+}
+
+// 只有显式设置的命令行参数覆盖配置文件中的全局开关，端点开关再覆盖全局开关
+func TestCollectorSelection(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		configured map[string]bool
+		endpoint   map[string]bool
+		want       map[string]bool
+	}{
+		{"只有配置文件", nil, map[string]bool{"info": false}, nil, map[string]bool{"info": false}},
+		{"命令行参数覆盖配置文件", []string{"--collectors.info"}, map[string]bool{"info": false}, nil, map[string]bool{"info": true}},
+		{"未设置的参数不覆盖配置文件", []string{"--collectors.hot_threads"}, map[string]bool{"info": false}, nil, map[string]bool{"info": false, "hot_threads": true}},
+		{"端点开关覆盖全局开关", []string{"--collectors.hot_threads"}, nil, map[string]bool{"hot_threads": false, "health_report": true}, map[string]bool{"hot_threads": false, "health_report": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			RegisterFlags(flags)
+			if err := flags.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			got := mergeSelection(collectorSelection(flags, tt.configured), tt.endpoint)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selection = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 所有端点的常量标签名一致，缺少的标签补为空字符串
+func TestEndpointLabels(t *testing.T) {
+	tests := []struct {
+		name      string
+		defaults  map[string]string
+		endpoints []map[string]string
+		want      []map[string]string
+	}{
+		{"没有标签", nil, []map[string]string{nil, nil}, []map[string]string{{}, {}}},
+		{"全局标签", map[string]string{"env": "prod"}, []map[string]string{nil, nil},
+			[]map[string]string{{"env": "prod"}, {"env": "prod"}}},
+		{"端点标签覆盖全局标签", map[string]string{"env": "prod"}, []map[string]string{{"env": "staging"}, nil},
+			[]map[string]string{{"env": "staging"}, {"env": "prod"}}},
+		{"补齐其他端点的标签", map[string]string{"env": "prod"}, []map[string]string{{"zone": "a"}, {"team": "logs"}},
+			[]map[string]string{{"env": "prod", "zone": "a", "team": ""}, {"env": "prod", "zone": "", "team": "logs"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoints := make([]server.EndpointConfig, len(tt.endpoints))
+			for i, labels := range tt.endpoints {
+				endpoints[i] = server.EndpointConfig{URL: "http://localhost:9600", Labels: labels}
+			}
+			if got := endpointLabels(tt.defaults, endpoints); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("endpointLabels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 补齐后标签不同的端点可以注册到同一个 registry
+func TestEndpointLabelsRegistry(t *testing.T) {
+	servers := []*logstashtest.Server{
+		logstashtest.NewTestServer(t, logstashtest.Version8),
+		logstashtest.NewTestServer(t, logstashtest.Version7),
+	}
+	endpoints := []server.EndpointConfig{
+		{URL: servers[0].URL, Labels: map[string]string{"zone": "a"}},
+		{URL: servers[1].URL, Labels: map[string]string{"team": "logs"}},
+	}
+	labels := endpointLabels(map[string]string{"env": "prod"}, endpoints)
+
+	registry := prometheus.NewRegistry()
+	for i, ep := range endpoints {
+		opts := endpointOptions(collector.DefaultOptions(), nil, labels[i], ep)
+		c, err := collector.NewWithOptions(ep.URL, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		if err := registry.Register(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := registry.Gather(); err != nil {
+		t.Error(err)
+	}
+}
+
+// --config.file、LOGSTASH_EXPORTER_CONFIG_FILE、EXPORTER_CONFIG_LOCATION 依次生效
+func TestConfigLocation(t *testing.T) {
+	tests := []struct {
+		name     string
+		flag     string
+		file     string
+		location string
+		want     string
+	}{
+		{"都未设置", "", "", "", ""},
+		{"EXPORTER_CONFIG_LOCATION", "", "", "/etc/c.yaml", "/etc/c.yaml"},
+		{"LOGSTASH_EXPORTER_CONFIG_FILE 优先", "", "/tmp/f.yaml", "/etc/c.yaml", "/tmp/f.yaml"},
+		{"命令行参数优先", "./config.yaml", "/tmp/f.yaml", "/etc/c.yaml", "./config.yaml"},
+	}
+
+	defer func(saved string) { configFile = saved }(configFile)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile = tt.flag
+			t.Setenv(configFileEnv, tt.file)
+			t.Setenv(configLocationEnv, tt.location)
+			if got := configLocation(); got != tt.want {
+				t.Errorf("configLocation() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
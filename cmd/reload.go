@@ -0,0 +1,404 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"go-logstash-exporter/pkg/collector"
+	"go-logstash-exporter/pkg/naming"
+	"go-logstash-exporter/pkg/server"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// exporter 保存根据配置创建的收集器，重新加载配置时只替换发生变化的部分
+// 未变化的端点继续使用原来的收集器，抓取耗时等统计不会因重新加载而重置
+type exporter struct {
+	mu sync.Mutex // 串行化重新加载
+
+	flags    *pflag.FlagSet
+	registry *prometheus.Registry
+	srv      *server.Server
+	pool     *collector.WorkerPool
+
+	config    *server.LogstashConfig        // 当前生效的配置
+	endpoints []*endpoint                   // 当前注册的端点收集器
+	clusters  []*collector.ClusterCollector // 当前注册的集群收集器
+	prober    *prober                       // 当前 /probe 使用的收集器工厂
+
+	reloadSuccess   prometheus.Gauge // 最近一次重新加载是否成功
+	reloadTimestamp prometheus.Gauge // 最近一次成功加载配置的时间
+}
+
+// endpoint 是一个已注册的端点收集器及创建它的参数
+type endpoint struct {
+	url       string
+	cluster   string
+	opts      collector.Options
+	collector *collector.LogstashCollector
+}
+
+// plan 是根据配置计算出的目标状态，尚未创建收集器
+type plan struct {
+	config    *server.LogstashConfig
+	endpoints []endpoint // 只设置了 url、cluster 和 opts
+	transform server.GathererTransform
+	prober    *prober // 应用失败时需要关闭
+}
+
+// newExporter 创建 exporter 并注册重新加载相关的指标
+func newExporter(flags *pflag.FlagSet, registry *prometheus.Registry, srv *server.Server, pool *collector.WorkerPool, config *server.LogstashConfig) *exporter {
+	e := &exporter{
+		flags:    flags,
+		registry: registry,
+		srv:      srv,
+		pool:     pool,
+		config:   config,
+
+		reloadSuccess: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: collector.Namespace,
+			Subsystem: "exporter",
+			Name:      "config_last_reload_successful",
+			Help:      "logstash_exporter: 最近一次重新加载配置是否成功。",
+		}),
+		reloadTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: collector.Namespace,
+			Subsystem: "exporter",
+			Name:      "config_last_reload_success_timestamp_seconds",
+			Help:      "logstash_exporter: 最近一次成功加载配置的时间（Unix 秒）。",
+		}),
+	}
+	e.reloadSuccess.Set(1)
+	e.reloadTimestamp.SetToCurrentTime()
+	registry.MustRegister(e.reloadSuccess, e.reloadTimestamp)
+
+	return e
+}
+
+// plan 校验配置并计算每个端点的收集器参数
+func (e *exporter) plan(config *server.LogstashConfig) (*plan, error) {
+	// 负数的时长与 check-config 和 JSON Schema 一样视为错误
+	if errs := server.ValidateDurations(config); len(errs) > 0 {
+		messages := make([]string, len(errs))
+		for i, err := range errs {
+			messages[i] = err.Error()
+		}
+		return nil, fmt.Errorf("配置无效: %s", strings.Join(messages, "; "))
+	}
+
+	// 重新标记规则在加载时编译
+	rules, err := relabelRules(config.MetricRelabelConfigs)
+	if err != nil {
+		return nil, fmt.Errorf("重新标记配置无效: %v", err)
+	}
+
+	// 命名兼容配置在重新标记之前执行，重新标记规则看到的是改写后的名称
+	profile, err := naming.Lookup(config.Naming.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("命名配置无效: %v", err)
+	}
+	profile = profile.WithInstanceLabel(config.InstanceLabel)
+
+	// 所有端点共享的收集器参数
+	opts := collectorOptions(config)
+	opts.Pool = e.pool
+
+	// 全局子收集器开关：命令行参数覆盖配置文件
+	globalCollectors := collectorSelection(e.flags, config.Collectors)
+
+	// 为 /probe 模块创建传输，证书等配置错误不必等到第一次探测才暴露
+	prober, err := newProber(config.Modules, opts, globalCollectors)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &plan{
+		config: config,
+		prober: prober,
+		// 暴露前依次补充单位元数据、改写兼容名称、执行重新标记
+		transform: func(g prometheus.Gatherer) prometheus.Gatherer {
+			return rules.Gatherer(profile.Gatherer(collector.UnitGatherer(g), config.Naming.DualEmit))
+		},
+	}
+
+	// 所有端点使用相同的常量标签名，缺少的标签以空字符串补齐
+	labels := endpointLabels(config.Labels, config.Endpoints)
+
+	for i, ep := range config.Endpoints {
+		url := strings.TrimSpace(ep.URL)
+		if url == "" {
+			continue
+		}
+
+		endpointOpts := endpointOptions(opts, globalCollectors, labels[i], ep)
+		p.endpoints = append(p.endpoints, endpoint{url: url, cluster: ep.Cluster, opts: endpointOpts})
+	}
+
+	return p, nil
+}
+
+// apply 使注册表与 p 一致：保留参数未变化的端点，注销删除或修改的端点，注册新增的端点并重建集群收集器
+// initial 为 true 时是启动时的首次加载：无法创建或注册的端点被跳过（严格模式下注册失败返回错误）；
+// 否则任何错误都会回滚到之前的状态并返回错误
+func (e *exporter) apply(p *plan, initial bool) error {
+	old := map[string]*endpoint{}
+	for _, ep := range e.endpoints {
+		old[ep.url] = ep
+	}
+
+	// 计算保留和新增的端点
+	var next, added []*endpoint
+	kept := map[string]bool{}
+	for i := range p.endpoints {
+		ep := p.endpoints[i]
+		if kept[ep.url] || containsURL(added, ep.url) {
+			fmt.Fprintf(os.Stderr, "重复的端点，已跳过 [%s]\n", ep.url)
+			continue
+		}
+
+		if prev, ok := old[ep.url]; ok && reflect.DeepEqual(prev.opts, ep.opts) {
+			prev.cluster = ep.cluster
+			next = append(next, prev)
+			kept[ep.url] = true
+			continue
+		}
+
+		c, err := collector.NewWithOptions(ep.url, ep.opts)
+		if err != nil {
+			if initial {
+				fmt.Fprintf(os.Stderr, "创建收集器失败 [%s]: %v\n", ep.url, err)
+				continue
+			}
+			closeEndpoints(added)
+			return fmt.Errorf("创建收集器失败 [%s]: %v", ep.url, err)
+		}
+		ep.collector = c
+		added = append(added, &ep)
+	}
+
+	var removed []*endpoint
+	for _, ep := range e.endpoints {
+		if !kept[ep.url] {
+			removed = append(removed, ep)
+		}
+	}
+
+	// 先注销删除和修改的端点以及所有集群收集器，避免与新的收集器冲突
+	for _, ep := range removed {
+		e.registry.Unregister(ep.collector)
+	}
+	for _, c := range e.clusters {
+		e.registry.Unregister(c)
+	}
+
+	// rollback 注销并关闭本次创建的收集器，恢复之前的注册状态
+	var registered []prometheus.Collector
+	rollback := func() {
+		for _, c := range registered {
+			e.registry.Unregister(c)
+		}
+		closeEndpoints(added)
+		for _, ep := range removed {
+			e.registry.MustRegister(ep.collector)
+		}
+		for _, c := range e.clusters {
+			e.registry.MustRegister(c)
+		}
+	}
+
+	for _, ep := range added {
+		if err := e.registry.Register(ep.collector); err != nil {
+			// 严格模式下指标描述冲突视为配置错误
+			if initial && !p.config.Strict {
+				fmt.Fprintf(os.Stderr, "注册收集器失败，已跳过 [%s]: %v\n", ep.url, err)
+				continue
+			}
+			rollback()
+			return fmt.Errorf("注册收集器失败 [%s]: %v", ep.url, err)
+		}
+		registered = append(registered, ep.collector)
+		next = append(next, ep)
+	}
+
+	// 按集群分组，为每个集群注册集群级指标
+	members := map[string][]*collector.LogstashCollector{}
+	for _, ep := range next {
+		if ep.cluster != "" {
+			members[ep.cluster] = append(members[ep.cluster], ep.collector)
+		}
+	}
+
+	// 同名集群延续之前的事件总数，重载不会造成计数器重置
+	previous := map[string]*collector.ClusterCollector{}
+	for _, c := range e.clusters {
+		previous[c.Name()] = c
+	}
+
+	var clusters []*collector.ClusterCollector
+	byName := map[string]*collector.ClusterCollector{}
+	for name, m := range members {
+		c, err := collector.NewClusterCollector(m, collector.ClusterOptions{
+			Name:       name,
+			MinHealthy: p.config.Clusters[name].MinHealthy,
+			Labels:     p.config.Labels,
+			Previous:   previous[name],
+		})
+		if err == nil {
+			err = e.registry.Register(c)
+		}
+		if err != nil {
+			if initial {
+				fmt.Fprintf(os.Stderr, "创建集群收集器失败 [%s]: %v\n", name, err)
+				continue
+			}
+			rollback()
+			return fmt.Errorf("创建集群收集器失败 [%s]: %v", name, err)
+		}
+		registered = append(registered, c)
+		clusters = append(clusters, c)
+		byName[name] = c
+	}
+
+	// 提交：启动新的收集器，停止被删除的收集器
+	for _, ep := range added {
+		if !containsURL(next, ep.url) {
+			// 首次加载时注册失败被跳过的收集器
+			ep.collector.Close()
+			continue
+		}
+		// 后台轮询模式下开始轮询，同步模式下不做任何事
+		ep.collector.Start()
+		fmt.Printf("添加 Logstash 实例: %s\n", ep.url)
+	}
+	for _, ep := range removed {
+		ep.collector.Close()
+		if !containsURL(next, ep.url) {
+			fmt.Printf("移除 Logstash 实例: %s\n", ep.url)
+		}
+	}
+	for name, c := range members {
+		if _, ok := byName[name]; ok {
+			fmt.Printf("添加 Logstash 集群: %s（%d 个节点）\n", name, len(c))
+		}
+	}
+
+	e.endpoints = next
+	e.clusters = clusters
+	e.config = p.config
+	e.srv.SetTransform(p.transform)
+	e.srv.SetProbe(p.prober.probe)
+	if e.prober != nil {
+		e.prober.Close()
+	}
+	e.prober = p.prober
+	e.srv.SetDrift(driftFunc(byName))
+	return nil
+}
+
+// closeEndpoints 关闭尚未提交的端点收集器
+func closeEndpoints(endpoints []*endpoint) {
+	for _, ep := range endpoints {
+		ep.collector.Close()
+	}
+}
+
+// containsURL 判断端点列表中是否包含 url
+func containsURL(endpoints []*endpoint, url string) bool {
+	for _, ep := range endpoints {
+		if ep.url == url {
+			return true
+		}
+	}
+	return false
+}
+
+// reload 重新读取配置文件并应用，失败时保留当前配置继续运行
+func (e *exporter) reload() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	err := e.reloadLocked()
+	if err != nil {
+		e.reloadSuccess.Set(0)
+		fmt.Fprintf(os.Stderr, "重新加载配置失败，继续使用当前配置: %v\n", err)
+		return err
+	}
+
+	e.reloadSuccess.Set(1)
+	e.reloadTimestamp.SetToCurrentTime()
+	fmt.Printf("重新加载配置成功\n")
+	return nil
+}
+
+// reloadLocked 执行重新加载，调用方需要持有 e.mu
+func (e *exporter) reloadLocked() error {
+	config, err := server.LoadConfig(configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %v", err)
+	}
+
+	// 以下配置在启动时确定，修改后需要重启才能生效
+	if config.Web.ListenAddress != e.config.Web.ListenAddress {
+		fmt.Fprintf(os.Stderr, "web.listen_address 的修改需要重启才能生效\n")
+	}
+	if config.MaxConcurrency != e.config.MaxConcurrency {
+		fmt.Fprintf(os.Stderr, "max_concurrency 的修改需要重启才能生效\n")
+	}
+	if config.Strict != e.config.Strict {
+		fmt.Fprintf(os.Stderr, "strict 的修改需要重启才能生效\n")
+		config.Strict = e.config.Strict
+	}
+	if config.WatchConfig != e.config.WatchConfig {
+		fmt.Fprintf(os.Stderr, "watch_config 的修改需要重启才能生效\n")
+	}
+
+	p, err := e.plan(config)
+	if err != nil {
+		return err
+	}
+	if err := e.apply(p, false); err != nil {
+		p.prober.Close()
+		return err
+	}
+	return nil
+}
+
+// watch 在收到 SIGHUP 时重新加载配置，watchFile 为 true 时还会在配置文件变化时重新加载
+// 没有配置文件时重新加载只重新读取环境变量，不监视文件
+func (e *exporter) watch(watchFile bool) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			e.reload()
+		}
+	}()
+
+	if !watchFile || configFile == "" {
+		return
+	}
+
+	// 编辑器保存时可能连续产生多个事件，短时间内的事件合并为一次重新加载
+	var mu sync.Mutex
+	var timer *time.Timer
+	v := viper.New()
+	v.SetConfigFile(configFile)
+	v.OnConfigChange(func(fsnotify.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.AfterFunc(time.Second, func() { e.reload() })
+	})
+	v.WatchConfig()
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"go-logstash-exporter/pkg/logstashtest"
+	"go-logstash-exporter/pkg/server"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/spf13/pflag"
+)
+
+// newTestExporter 使用临时配置文件创建 exporter 并完成首次加载
+func newTestExporter(t *testing.T, content string) *exporter {
+	t.Helper()
+
+	// RegisterFlags 会把 configFile 重置为默认值，需要先注册参数
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	RegisterFlags(flags)
+
+	configFile = filepath.Join(t.TempDir(), "config.yaml")
+	t.Cleanup(func() { configFile = "" })
+	writeConfig(t, content)
+
+	config, err := server.LoadConfig(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := prometheus.NewRegistry()
+	e := newExporter(flags, registry, server.New(":0", registry), nil, config)
+
+	p, err := e.plan(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.apply(p, true); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// writeConfig 覆盖临时配置文件
+func writeConfig(t *testing.T, content string) {
+	t.Helper()
+	if err := os.WriteFile(configFile, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// endpointURLs 返回 exporter 当前的端点地址
+func endpointURLs(e *exporter) []string {
+	var urls []string
+	for _, ep := range e.endpoints {
+		urls = append(urls, ep.url)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+func TestReloadDiff(t *testing.T) {
+	a := logstashtest.NewTestServer(t, logstashtest.Version8)
+	b := logstashtest.NewTestServer(t, logstashtest.Version7)
+	c := logstashtest.NewTestServer(t, logstashtest.Version6)
+
+	e := newTestExporter(t, fmt.Sprintf("endpoints:\n  - %s\n  - %s\n", a.URL, b.URL))
+	kept := e.endpoints[0].collector
+
+	// 保留 a，删除 b，新增 c
+	writeConfig(t, fmt.Sprintf("endpoints:\n  - %s\n  - %s\n", a.URL, c.URL))
+	if err := e.reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{a.URL, c.URL}
+	sort.Strings(want)
+	if got := endpointURLs(e); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("endpoints = %v, want %v", got, want)
+	}
+	for _, ep := range e.endpoints {
+		if ep.url == a.URL && ep.collector != kept {
+			t.Error("参数未变化的端点被重新创建")
+		}
+	}
+	if got := testutil.ToFloat64(e.reloadSuccess); got != 1 {
+		t.Errorf("config_last_reload_successful = %v, want 1", got)
+	}
+
+	// 修改参数的端点被重新创建
+	writeConfig(t, fmt.Sprintf("timeout: 3s\nendpoints:\n  - %s\n  - %s\n", a.URL, c.URL))
+	if err := e.reload(); err != nil {
+		t.Fatal(err)
+	}
+	for _, ep := range e.endpoints {
+		if ep.url == a.URL && ep.collector == kept {
+			t.Error("参数变化的端点没有被重新创建")
+		}
+	}
+}
+
+func TestReloadRollback(t *testing.T) {
+	a := logstashtest.NewTestServer(t, logstashtest.Version8)
+	b := logstashtest.NewTestServer(t, logstashtest.Version7)
+
+	e := newTestExporter(t, fmt.Sprintf("endpoints:\n  - %s\n", a.URL))
+	before := e.endpoints
+
+	// 第二个新端点的证书无法加载，已经创建的第一个新端点被关闭，配置保持不变
+	writeConfig(t, fmt.Sprintf(`endpoints:
+  - %s
+  - url: %s
+  - url: https://localhost:1
+    tls:
+      ca_file: /nonexistent/ca.pem
+`, a.URL, b.URL))
+	if err := e.reload(); err == nil || !strings.Contains(err.Error(), "创建收集器失败") {
+		t.Fatalf("证书无法加载时重新加载应当失败: %v", err)
+	}
+
+	if got := endpointURLs(e); fmt.Sprint(got) != fmt.Sprint([]string{a.URL}) {
+		t.Errorf("endpoints = %v, want [%s]", got, a.URL)
+	}
+	if e.endpoints[0] != before[0] {
+		t.Error("失败的重新加载替换了原有的端点")
+	}
+	if got := testutil.ToFloat64(e.reloadSuccess); got != 0 {
+		t.Errorf("config_last_reload_successful = %v, want 0", got)
+	}
+
+	// 原有端点仍然注册在注册表中
+	families, err := e.registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, family := range families {
+		if family.GetName() == "logstash_exporter_circuit_breaker_state" {
+			found = len(family.GetMetric()) == 1
+		}
+	}
+	if !found {
+		t.Error("回滚后注册表中的端点不正确")
+	}
+}
+
+// 负数的时长与 check-config 一样被拒绝，之前的配置继续生效
+func TestReloadRejectsNegativeDurations(t *testing.T) {
+	a := logstashtest.NewTestServer(t, logstashtest.Version8)
+	e := newTestExporter(t, fmt.Sprintf("endpoints:\n  - %s\n", a.URL))
+
+	writeConfig(t, fmt.Sprintf("poll:\n  max_age: -1m\nendpoints:\n  - %s\n", a.URL))
+	if err := e.reload(); err == nil || !strings.Contains(err.Error(), "poll.max_age") {
+		t.Fatalf("负数的 poll.max_age 应当被拒绝: %v", err)
+	}
+	if e.config.Poll.MaxAge < 0 {
+		t.Error("失败的重新加载替换了原有的配置")
+	}
+}
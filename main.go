@@ -8,10 +8,6 @@ import (
 	"github.com/spf13/cobra"
 )
 
-var (
-	configFile string // 配置文件路径
-)
-
 func main() {
 	rootCmd := &cobra.Command{
 		Use:   "logstash_exporter",
@@ -20,7 +16,8 @@ func main() {
 		Run:   cmd.Run,
 	}
 
-	rootCmd.Flags().StringVar(&configFile, "config.file", "", "配置文件路径")
+	cmd.RegisterFlags(rootCmd.Flags())
+	rootCmd.AddCommand(cmd.NewCheckConfigCommand(), cmd.NewConfigCommand())
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
@@ -1,36 +1,136 @@
 package collector
 
 import (
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"strings"
+	"time"
 )
 
+// ErrBodyTooLarge 表示响应体超过了允许的最大大小
+var ErrBodyTooLarge = errors.New("响应体超过最大允许大小")
+
 // HTTPHandler HTTP处理器结构体
 type HTTPHandler struct {
-	Endpoint string // 端点URL
+	Endpoint string          // 端点URL
+	Client   *http.Client    // HTTP 客户端，为空时使用 http.DefaultClient
+	Context  context.Context // 请求上下文，携带本次抓取的截止时间
+	Retry    RetryPolicy     // 瞬时错误的重试策略
+	Breaker  *CircuitBreaker // 端点熔断器，为空时不熔断
 }
 
 // Get 发送HTTP GET请求并返回响应
+// 瞬时错误会在截止时间内按退避策略重试，最终结果计入熔断器
+// 只有瞬时错误计为失败：4xx 等永久错误说明端点可以正常响应（例如旧版本没有 /_health_report），不应触发熔断
 func (h *HTTPHandler) Get() (http.Response, error) {
-	response, err := http.Get(h.Endpoint)
+	if err := h.Breaker.Allow(); err != nil {
+		return http.Response{}, err
+	}
+
+	response, err := h.getWithRetry()
 	if err != nil {
+		if isTransient(err) {
+			h.Breaker.Failure()
+		} else {
+			h.Breaker.Success()
+		}
 		return http.Response{}, err
 	}
 
+	h.Breaker.Success()
 	return *response, nil
 }
 
+// getWithRetry 按重试策略发送请求，直到成功、遇到非瞬时错误或超过截止时间
+func (h *HTTPHandler) getWithRetry() (*http.Response, error) {
+	ctx := h.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	attempts := h.Retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			wait := h.Retry.backoff(attempt - 1)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+				break
+			}
+			Debugf("第 %d 次重试 %s，等待 %s: %v", attempt-1, h.Endpoint, wait, lastErr)
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, lastErr
+			case <-timer.C:
+			}
+		}
+
+		response, err := h.do(ctx)
+		if err == nil {
+			return response, nil
+		}
+
+		lastErr = err
+		if !isTransient(err) || ctx.Err() != nil {
+			break
+		}
+	}
+
+	return nil, lastErr
+}
+
+// do 发送单次请求，非 2xx 状态码会被转换为 StatusError
+func (h *HTTPHandler) do(ctx context.Context) (*http.Response, error) {
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, h.Endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	// 显式声明 gzip 后 http.Transport 不再自动解压，由 decodeResponse 负责流式解压
+	request.Header.Set("Accept-Encoding", "gzip")
+	request.Header.Set("Accept", "application/json")
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		response.Body.Close()
+		return nil, &StatusError{URL: h.Endpoint, StatusCode: response.StatusCode}
+	}
+
+	return response, nil
+}
+
 // HTTPHandlerInterface HTTP处理器接口
 type HTTPHandlerInterface interface {
 	Get() (http.Response, error)
 }
 
 // getMetrics 从HTTP处理器获取指标数据并解析到目标结构体
-func getMetrics(h HTTPHandlerInterface, target interface{}) error {
+// maxBodySize 限制解压后的响应体大小（小于等于 0 表示不限制），返回实际接收的字节数
+func getMetrics(h HTTPHandlerInterface, target interface{}, maxBodySize int64) (int64, error) {
 	response, err := h.Get()
 	if err != nil {
 		Errorf("无法获取指标: %s", err)
-		return nil
+		return 0, err
 	}
 
 	defer func() {
@@ -40,9 +140,79 @@ func getMetrics(h HTTPHandlerInterface, target interface{}) error {
 		}
 	}()
 
-	if err := json.NewDecoder(response.Body).Decode(target); err != nil {
+	received, err := decodeResponse(&response, target, maxBodySize)
+	if err != nil {
 		Errorf("无法解析Logstash响应json: %s", err)
+		return received, err
+	}
+
+	return received, nil
+}
+
+// decodeResponse 流式解压并解析响应体
+// HTML 响应（例如代理返回的错误页）和超出大小限制的响应会返回明确的错误，
+// 而不会被完整读入内存
+func decodeResponse(response *http.Response, target interface{}, maxBodySize int64) (int64, error) {
+	counter := &countingReader{reader: response.Body}
+
+	if contentType := response.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err == nil && strings.Contains(mediaType, "html") {
+			return 0, fmt.Errorf("响应类型 %q 不是 JSON", mediaType)
+		}
+	}
+
+	var body io.Reader = counter
+	if strings.EqualFold(response.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(counter)
+		if err != nil {
+			return counter.n, fmt.Errorf("无法解压 gzip 响应: %w", err)
+		}
+		defer gz.Close()
+		body = gz
+	}
+
+	if maxBodySize > 0 {
+		body = &limitedReader{reader: body, remaining: maxBodySize}
 	}
 
-	return nil
-}
\ No newline at end of file
+	if err := json.NewDecoder(body).Decode(target); err != nil {
+		if errors.Is(err, ErrBodyTooLarge) {
+			return counter.n, fmt.Errorf("%w（上限 %d 字节）", ErrBodyTooLarge, maxBodySize)
+		}
+		return counter.n, err
+	}
+
+	return counter.n, nil
+}
+
+// countingReader 统计从底层读取的字节数
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// limitedReader 与 io.LimitReader 类似，但超出限制时返回 ErrBodyTooLarge 而不是 io.EOF，
+// 避免被截断的 JSON 被误报为语法错误
+type limitedReader struct {
+	reader    io.Reader
+	remaining int64
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, ErrBodyTooLarge
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n, err := r.reader.Read(p)
+	r.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,210 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go-logstash-exporter/pkg/logstashtest"
+)
+
+// testClient 创建访问测试服务器的客户端，不退避、不熔断
+func testClient(t *testing.T, server *logstashtest.Server, modify func(*Options)) *APIClient {
+	t.Helper()
+
+	opts := DefaultOptions()
+	opts.Retry.InitialBackoff = 0
+	opts.Breaker.FailureThreshold = 0
+	if modify != nil {
+		modify(&opts)
+	}
+	client, err := NewAPIClient(server.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	b := NewCircuitBreaker(BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute})
+	b.now = func() time.Time { return now }
+
+	b.Failure()
+	if b.State() != BreakerClosed || b.Allow() != nil {
+		t.Fatal("未达到阈值时熔断器应保持关闭")
+	}
+	b.Failure()
+	if b.State() != BreakerOpen || !errors.Is(b.Allow(), ErrCircuitOpen) {
+		t.Fatal("达到阈值后熔断器应打开")
+	}
+
+	// 超时后进入半开状态，只放行一个探测请求
+	now = now.Add(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("超时后应放行探测请求: %v", err)
+	}
+	if b.State() != BreakerHalfOpen || !errors.Is(b.Allow(), ErrCircuitOpen) {
+		t.Fatal("半开状态下只放行一个探测请求")
+	}
+
+	// 探测失败重新打开
+	b.Failure()
+	if b.State() != BreakerOpen {
+		t.Fatal("探测失败后熔断器应重新打开")
+	}
+
+	// 探测成功关闭
+	now = now.Add(time.Minute)
+	b.Allow()
+	b.Success()
+	if b.State() != BreakerClosed || b.Allow() != nil {
+		t.Fatal("探测成功后熔断器应关闭")
+	}
+}
+
+func TestRetryTransientErrors(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8,
+		logstashtest.WithFault(logstashtest.Fault{StatusCode: http.StatusServiceUnavailable}))
+	client := testClient(t, server, nil)
+
+	_, err := client.NodeStats()
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("err = %v, want 503", err)
+	}
+	if got := server.Requests(logstashtest.PathNodeStats); got != DefaultRetryPolicy().MaxAttempts {
+		t.Errorf("瞬时错误请求了 %d 次，want %d", got, DefaultRetryPolicy().MaxAttempts)
+	}
+
+	// 故障恢复后成功
+	server.SetFault(logstashtest.Fault{})
+	if _, err := client.NodeStats(); err != nil {
+		t.Errorf("故障恢复后请求失败: %v", err)
+	}
+}
+
+// 永久错误不重试，也不计入熔断器
+func TestPermanentErrorsDoNotTripBreaker(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version7)
+	client := testClient(t, server, func(opts *Options) {
+		opts.Breaker = BreakerConfig{FailureThreshold: 1, OpenTimeout: time.Hour}
+	})
+
+	// 7.x 没有 /_health_report
+	for i := 0; i < 3; i++ {
+		_, err := client.HealthReport()
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+			t.Fatalf("err = %v, want 404", err)
+		}
+	}
+	if got := server.Requests(logstashtest.PathHealthReport); got != 3 {
+		t.Errorf("404 请求了 %d 次，want 3（不重试、不熔断）", got)
+	}
+	if client.BreakerState() != BreakerClosed {
+		t.Errorf("404 打开了熔断器")
+	}
+	if _, err := client.NodeStats(); err != nil {
+		t.Errorf("404 之后其他 API 被熔断: %v", err)
+	}
+
+	// 瞬时错误打开熔断器
+	server.SetFault(logstashtest.Fault{StatusCode: http.StatusBadGateway})
+	client.NodeStats()
+	if client.BreakerState() != BreakerOpen {
+		t.Errorf("502 之后熔断器状态 = %s, want open", client.BreakerState())
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8,
+		logstashtest.WithFault(logstashtest.Fault{Delay: time.Second}))
+	client := testClient(t, server, func(opts *Options) {
+		opts.Timeout = 50 * time.Millisecond
+	})
+
+	begin := time.Now()
+	_, err := client.NodeStats()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want 超时", err)
+	}
+	if elapsed := time.Since(begin); elapsed > 500*time.Millisecond {
+		t.Errorf("超时后仍等待了 %s", elapsed)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8, logstashtest.WithBasicAuth("monitor", "secret"))
+
+	_, err := testClient(t, server, nil).NodeInfo()
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("未认证: err = %v, want 401", err)
+	}
+
+	client := testClient(t, server, func(opts *Options) {
+		opts.Auth = AuthConfig{Username: "monitor", Password: "secret"}
+	})
+	if _, err := client.NodeInfo(); err != nil {
+		t.Errorf("认证后请求失败: %v", err)
+	}
+}
+
+func TestBodyLimit(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8, logstashtest.WithGzip())
+	fixture, _ := logstashtest.Fixture(logstashtest.Version8, logstashtest.PathNodeStats)
+
+	// 上限按解压后的大小计算，接收的字节数按压缩后的大小统计
+	client := testClient(t, server, func(opts *Options) {
+		opts.MaxBodySize = int64(len(fixture))
+	})
+	if _, err := client.NodeStats(); err != nil {
+		t.Fatalf("未超过上限的响应失败: %v", err)
+	}
+	if received := client.BytesReceived()[nodeStatsCollectorName]; received <= 0 || received >= int64(len(fixture)) {
+		t.Errorf("接收 %d 字节，应小于解压后的 %d 字节", received, len(fixture))
+	}
+
+	client = testClient(t, server, func(opts *Options) {
+		opts.MaxBodySize = int64(len(fixture)) / 2
+	})
+	if _, err := client.NodeStats(); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("err = %v, want ErrBodyTooLarge", err)
+	}
+}
+
+func TestInvalidResponses(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8,
+		logstashtest.WithFault(logstashtest.Fault{Truncate: true}))
+	client := testClient(t, server, nil)
+
+	if _, err := client.NodeStats(); err == nil {
+		t.Error("截断的 JSON 应当解析失败")
+	}
+	// 截断不是瞬时错误，不重试
+	if got := server.Requests(logstashtest.PathNodeStats); got != 1 {
+		t.Errorf("截断的响应请求了 %d 次，want 1", got)
+	}
+
+	// 代理返回的 HTML 错误页
+	response := &http.Response{
+		Header: http.Header{"Content-Type": {"text/html"}},
+		Body:   http.NoBody,
+	}
+	if _, err := decodeResponse(response, &struct{}{}, 0); err == nil || !strings.Contains(err.Error(), "html") {
+		t.Errorf("HTML 响应: err = %v", err)
+	}
+}
+
+func TestLimitedReader(t *testing.T) {
+	var target map[string]interface{}
+	response := &http.Response{Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{"a":"0123456789"}`))}
+	if _, err := decodeResponse(response, &target, 5); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("err = %v, want ErrBodyTooLarge", err)
+	}
+}
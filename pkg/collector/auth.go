@@ -0,0 +1,114 @@
+package collector
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// AuthConfig 定义了访问 Logstash API 使用的认证信息
+// 同时设置用户名和 Bearer Token 时使用 Basic 认证
+type AuthConfig struct {
+	Username    string // Basic 认证用户名
+	Password    string // Basic 认证密码
+	BearerToken string // Bearer Token
+}
+
+// TLSConfig 定义了访问 HTTPS Logstash API 的 TLS 参数
+type TLSConfig struct {
+	CAFile             string // 校验服务端证书的 CA 证书文件
+	CertFile           string // 客户端证书文件
+	KeyFile            string // 客户端私钥文件
+	ServerName         string // 校验服务端证书时使用的主机名
+	InsecureSkipVerify bool   // 跳过服务端证书校验
+}
+
+// enabled 判断是否设置了任何 TLS 参数
+func (c TLSConfig) enabled() bool {
+	return c != TLSConfig{}
+}
+
+// newTLSConfig 加载证书文件并生成 tls.Config
+func newTLSConfig(c TLSConfig) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 CA 证书失败: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA 证书文件 %s 中没有有效的证书", c.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		if c.CertFile == "" || c.KeyFile == "" {
+			return nil, fmt.Errorf("客户端证书和私钥必须同时设置")
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// NewHTTPTransport 根据 TLS 和认证配置创建底层 RoundTripper
+// 没有 TLS 配置时复用 http.DefaultTransport 的连接池；不再使用时调用 CloseTransport 释放空闲连接
+func NewHTTPTransport(auth AuthConfig, tlsConfig TLSConfig) (http.RoundTripper, error) {
+	var transport http.RoundTripper = http.DefaultTransport
+	if tlsConfig.enabled() {
+		config, err := newTLSConfig(tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.TLSClientConfig = config
+		transport = t
+	}
+
+	if auth == (AuthConfig{}) {
+		return transport, nil
+	}
+	return &authTransport{auth: auth, next: transport}, nil
+}
+
+// CloseTransport 关闭 NewHTTPTransport 创建的传输中的空闲连接
+// 共享的 http.DefaultTransport 不会被关闭
+func CloseTransport(transport http.RoundTripper) {
+	if t, ok := transport.(*authTransport); ok {
+		transport = t.next
+	}
+	if t, ok := transport.(*http.Transport); ok && transport != http.DefaultTransport {
+		t.CloseIdleConnections()
+	}
+}
+
+// authTransport 为每个请求添加认证头
+type authTransport struct {
+	auth AuthConfig
+	next http.RoundTripper
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+func (t *authTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	// RoundTripper 不应修改原请求
+	request = request.Clone(request.Context())
+	switch {
+	case t.auth.Username != "":
+		request.SetBasicAuth(t.auth.Username, t.auth.Password)
+	case t.auth.BearerToken != "":
+		request.Header.Set("Authorization", "Bearer "+t.auth.BearerToken)
+	}
+	return t.next.RoundTrip(request)
+}
@@ -0,0 +1,140 @@
+package collector
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 表示端点熔断器处于打开状态，请求被直接拒绝
+var ErrCircuitOpen = errors.New("熔断器已打开，跳过对该端点的请求")
+
+// BreakerState 表示熔断器的状态
+type BreakerState int
+
+// 熔断器状态，数值即导出指标的取值
+const (
+	BreakerClosed   BreakerState = iota // 关闭：请求正常放行
+	BreakerOpen                         // 打开：请求被直接拒绝
+	BreakerHalfOpen                     // 半开：放行单个探测请求
+)
+
+// String 返回熔断器状态的文字描述
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// BreakerConfig 定义了端点熔断器的参数
+type BreakerConfig struct {
+	FailureThreshold int           // 连续失败多少次后打开熔断器，小于等于 0 表示禁用熔断
+	OpenTimeout      time.Duration // 熔断器打开后多久进入半开状态进行探测
+}
+
+// DefaultBreakerConfig 返回默认的熔断器配置
+func DefaultBreakerConfig() BreakerConfig {
+	return BreakerConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	}
+}
+
+// CircuitBreaker 是单个 Logstash 端点的熔断器
+// 连续失败达到阈值后打开，打开期间拒绝请求；超时后进入半开状态，
+// 只放行一个探测请求，探测成功则关闭，失败则重新打开
+type CircuitBreaker struct {
+	config BreakerConfig
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int       // 连续失败次数
+	openedAt time.Time // 最近一次打开的时间
+	probing  bool      // 半开状态下是否已有探测请求在进行中
+	now      func() time.Time
+}
+
+// NewCircuitBreaker 创建新的熔断器
+func NewCircuitBreaker(config BreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Allow 判断当前是否允许发起请求
+// 返回 ErrCircuitOpen 表示请求应被跳过
+func (b *CircuitBreaker) Allow() error {
+	if b == nil || b.config.FailureThreshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.config.OpenTimeout {
+			return ErrCircuitOpen
+		}
+		b.state = BreakerHalfOpen
+		b.probing = true
+		return nil
+	case BreakerHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Success 记录一次成功的请求
+func (b *CircuitBreaker) Success() {
+	if b == nil || b.config.FailureThreshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = BreakerClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// Failure 记录一次失败的请求
+func (b *CircuitBreaker) Failure() {
+	if b == nil || b.config.FailureThreshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.state = BreakerOpen
+		b.openedAt = b.now()
+	}
+	b.probing = false
+}
+
+// State 返回熔断器当前状态
+func (b *CircuitBreaker) State() BreakerState {
+	if b == nil {
+		return BreakerClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
@@ -0,0 +1,76 @@
+package collector
+
+// overflowPluginID 是超出序列上限的插件指标折叠后使用的 plugin、plugin_id 和 plugin_type
+const overflowPluginID = "__other__"
+
+// pluginValue 是某个插件在某个指标上的取值
+type pluginValue struct {
+	plugin pluginStats
+	value  float64
+}
+
+// seriesGuard 限制单个指标在单个实例上发送的插件序列数
+// 超出上限时每个 pipeline 剩余的序列求和，折叠到一个 plugin_id="__other__" 的桶中，
+// 折叠桶本身也计入上限
+type seriesGuard struct {
+	limit  int // 序列上限，小于等于 0 表示不限制
+	budget int // 可以直接发送的序列数，已扣除折叠桶占用的序列
+	count  int // 已发送的序列数
+	folded int // 被折叠的序列数
+
+	buckets map[string]float64 // 按 pipeline 求和的折叠桶
+	order   []string           // 折叠桶的创建顺序，保证输出稳定
+}
+
+// newSeriesGuard 创建序列上限检查器，counts 是按发送顺序排列的每个 pipeline 的序列数
+// 超出上限时，每个发生折叠的 pipeline 占用一个序列，直接发送的序列数相应减少；
+// 只有发生折叠的 pipeline 数本身超过上限时，总序列数才会超过上限
+func newSeriesGuard(limit int, counts []int) *seriesGuard {
+	g := &seriesGuard{limit: limit, budget: limit}
+
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	if limit <= 0 || total <= limit {
+		return g
+	}
+
+	// 预留的折叠桶越多，直接发送的序列越少，发生折叠的 pipeline 也可能越多，直到不再增加
+	reserved := 1
+	for {
+		g.budget = max(limit-reserved, 0)
+		overflowing, seen := 0, 0
+		for _, n := range counts {
+			if n > 0 && seen+n > g.budget {
+				overflowing++
+			}
+			seen += n
+		}
+		if overflowing <= reserved {
+			return g
+		}
+		reserved = overflowing
+	}
+}
+
+// admit 判断序列是否可以直接发送，未超出上限时计数加一
+func (g *seriesGuard) admit() bool {
+	if g.limit <= 0 || g.count < g.budget {
+		g.count++
+		return true
+	}
+	return false
+}
+
+// fold 将超出上限的序列累加到所属 pipeline 的折叠桶
+func (g *seriesGuard) fold(pipeline string, value float64) {
+	if g.buckets == nil {
+		g.buckets = map[string]float64{}
+	}
+	if _, ok := g.buckets[pipeline]; !ok {
+		g.order = append(g.order, pipeline)
+	}
+	g.buckets[pipeline] += value
+	g.folded++
+}
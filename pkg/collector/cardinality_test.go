@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"testing"
+
+	"go-logstash-exporter/pkg/logstashtest"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSeriesGuardBudget(t *testing.T) {
+	tests := []struct {
+		name   string
+		limit  int
+		counts []int
+		want   int // 可以直接发送的序列数
+	}{
+		{"不限制", 0, []int{10, 10}, 0},
+		{"未超出上限", 20, []int{10, 10}, 20},
+		{"只有最后一个 pipeline 折叠", 10, []int{5, 10}, 9},
+		{"只有第二个 pipeline 折叠", 6, []int{5, 5}, 5},
+		{"两个 pipeline 都折叠", 6, []int{6, 5}, 4},
+		{"pipeline 数超过上限", 2, []int{3, 3, 3}, 0},
+		{"空 pipeline 不占用折叠桶", 4, []int{3, 0, 3}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newSeriesGuard(tt.limit, tt.counts)
+			if tt.limit <= 0 {
+				return
+			}
+			if g.budget != tt.want {
+				t.Errorf("budget = %d, want %d", g.budget, tt.want)
+			}
+
+			// 按顺序发送，直接发送和折叠桶的总数不超过上限（pipeline 数超过上限时除外）
+			for i, n := range tt.counts {
+				for j := 0; j < n; j++ {
+					if !g.admit() {
+						g.fold(string(rune('a'+i)), 1)
+					}
+				}
+			}
+			nonEmpty := 0
+			for _, n := range tt.counts {
+				if n > 0 {
+					nonEmpty++
+				}
+			}
+			if total := g.count + len(g.buckets); total > max(tt.limit, nonEmpty) {
+				t.Errorf("发送了 %d 个序列，超过上限 %d", total, tt.limit)
+			}
+		})
+	}
+}
+
+func TestMaxSeriesPerMetric(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8)
+
+	count := func(limit int) (series int, other float64, total float64) {
+		c, err := NewWithOptions(server.URL, Options{
+			MaxSeriesPerMetric: limit,
+			Collectors:         map[string]bool{"node_stats": true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		registry := prometheus.NewRegistry()
+		registry.MustRegister(c)
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, family := range families {
+			if family.GetName() != "logstash_node_plugin_events_in_total" {
+				continue
+			}
+			for _, m := range family.GetMetric() {
+				series++
+				total += m.GetCounter().GetValue()
+				for _, label := range m.GetLabel() {
+					if label.GetName() == "plugin_id" && label.GetValue() == overflowPluginID {
+						other += m.GetCounter().GetValue()
+					}
+				}
+			}
+		}
+		return series, other, total
+	}
+
+	all, _, want := count(0)
+	if all < 3 {
+		t.Fatalf("fixture 中只有 %d 个插件序列", all)
+	}
+
+	limit := all - 1
+	series, other, total := count(limit)
+	if series > limit {
+		t.Errorf("限制为 %d 时发送了 %d 个序列", limit, series)
+	}
+	if other == 0 && want != 0 {
+		t.Errorf("没有发送折叠序列")
+	}
+	if total != want {
+		t.Errorf("折叠后的总和 = %v, want %v", total, want)
+	}
+}
@@ -0,0 +1,217 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Options 定义了单个 Logstash 端点收集器的可调参数
+type Options struct {
+	Timeout time.Duration // 单次抓取的截止时间，重试也必须在此时间内完成
+	Retry   RetryPolicy   // 瞬时错误的重试策略
+	Breaker BreakerConfig // 端点熔断器配置
+
+	MaxBodySize int64         // 解压后响应体的最大字节数，小于等于 0 表示不限制
+	NodeInfoTTL time.Duration // 节点信息缓存有效期，小于等于 0 表示不缓存
+
+	Transport TransportConfig // 录制或回放 Logstash API 响应的传输配置
+
+	Strict bool // 严格模式：创建收集器时检查指标描述是否重复
+
+	// Collectors 按配置键名开关子收集器，未设置的子收集器使用默认值
+	Collectors map[string]bool
+
+	Filters FilterConfig // 节点统计指标的 pipeline 和插件过滤规则
+
+	// MaxSeriesPerMetric 是单个插件指标在单个实例上的序列上限，超出的序列折叠到 plugin_id="__other__"
+	// 小于等于 0 表示不限制
+	MaxSeriesPerMetric int
+
+	Poll PollConfig // 后台轮询配置，Interval 为 0 时每次抓取同步请求 Logstash
+
+	// MinRefreshInterval 是同步模式下两次请求 Logstash 的最小间隔，间隔内的抓取复用上一次的结果
+	// 小于等于 0 表示只合并并发的抓取
+	MinRefreshInterval time.Duration
+
+	Pool *WorkerPool // 所有端点共享的工作池，为空时不限制并发
+
+	// Labels 是附加在端点所有指标上的常量标签（例如 cluster、env），不能包含实例标签
+	// 同一注册表中各端点的标签名必须一致，缺少的标签以空字符串补齐
+	Labels map[string]string
+
+	// InstanceLabel 是标识 Logstash 节点的标签名，为空时使用 instance
+	// 改为其他名称（例如 logstash_instance）可以避免与 Prometheus 的 instance 标签冲突
+	InstanceLabel string
+
+	// Name 是实例标签的取值，为空时使用端点地址中的 host:port
+	Name string
+
+	Auth AuthConfig // 访问 Logstash API 的认证信息
+	TLS  TLSConfig  // 访问 HTTPS Logstash API 的 TLS 参数
+
+	// HTTPTransport 是由调用方创建并负责关闭的底层传输（见 NewHTTPTransport），设置后忽略 Auth 和 TLS
+	// 多个收集器共享同一传输时可以复用连接，例如同一模块的 /probe 请求
+	HTTPTransport http.RoundTripper
+
+	// CircuitBreaker 是由调用方创建的熔断器，为空时根据 Breaker 新建
+	// 为同一目标重复创建收集器时传入同一熔断器，熔断状态才能跨收集器保留
+	CircuitBreaker *CircuitBreaker
+}
+
+// DefaultOptions 返回默认的收集器参数
+func DefaultOptions() Options {
+	return Options{
+		Timeout: 10 * time.Second,
+		Retry:   DefaultRetryPolicy(),
+		Breaker: DefaultBreakerConfig(),
+
+		MaxBodySize: 32 << 20,
+		NodeInfoTTL: 5 * time.Minute,
+	}
+}
+
+// APIClient 封装了访问单个 Logstash 节点 API 所需的 HTTP 客户端、重试策略和熔断器
+// 同一端点的所有子收集器共享一个 APIClient，从而共享熔断状态
+type APIClient struct {
+	endpoint string            // Logstash API 端点
+	client   *http.Client      // HTTP 客户端
+	base     http.RoundTripper // 自行创建的底层传输，使用调用方传入的传输时为空
+	timeout  time.Duration     // 单次抓取的截止时间
+	retry    RetryPolicy       // 重试策略
+	breaker  *CircuitBreaker   // 端点熔断器
+
+	maxBodySize int64 // 解压后响应体的最大字节数
+
+	mu            sync.Mutex       // 保护 bytesReceived
+	bytesReceived map[string]int64 // 按子收集器统计的接收字节数（压缩后）
+}
+
+// NewAPIClient 创建新的 Logstash API 客户端，TLS 证书加载失败时返回错误
+func NewAPIClient(endpoint string, opts Options) (*APIClient, error) {
+	transport := opts.HTTPTransport
+	var base http.RoundTripper
+	if transport == nil {
+		var err error
+		if transport, err = NewHTTPTransport(opts.Auth, opts.TLS); err != nil {
+			return nil, err
+		}
+		base = transport
+	}
+
+	breaker := opts.CircuitBreaker
+	if breaker == nil {
+		breaker = NewCircuitBreaker(opts.Breaker)
+	}
+
+	return &APIClient{
+		endpoint: endpoint,
+		client:   &http.Client{Transport: newTransport(opts.Transport, transport)},
+		base:     base,
+		timeout:  opts.Timeout,
+		retry:    opts.Retry,
+		breaker:  breaker,
+
+		maxBodySize:   opts.MaxBodySize,
+		bytesReceived: map[string]int64{},
+	}, nil
+}
+
+// Close 释放客户端自行创建的传输中的空闲连接，调用方传入的传输由调用方负责关闭
+func (c *APIClient) Close() {
+	if c.base != nil {
+		CloseTransport(c.base)
+	}
+}
+
+// Endpoint 返回客户端访问的 Logstash API 端点
+func (c *APIClient) Endpoint() string {
+	return c.endpoint
+}
+
+// BreakerState 返回端点熔断器的当前状态
+func (c *APIClient) BreakerState() BreakerState {
+	return c.breaker.State()
+}
+
+// BytesReceived 返回按子收集器统计的累计接收字节数
+func (c *APIClient) BytesReceived() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := make(map[string]int64, len(c.bytesReceived))
+	for name, n := range c.bytesReceived {
+		result[name] = n
+	}
+	return result
+}
+
+// addBytesReceived 累加子收集器的接收字节数
+func (c *APIClient) addBytesReceived(collector string, n int64) {
+	if n <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.bytesReceived[collector] += n
+}
+
+// get 请求指定路径并将响应解析到 target，接收的字节数计入 collector 名下
+func (c *APIClient) get(collector, path string, target interface{}) error {
+	ctx := context.Background()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	handler := &HTTPHandler{
+		Endpoint: c.endpoint + path,
+		Client:   c.client,
+		Context:  ctx,
+		Retry:    c.retry,
+		Breaker:  c.breaker,
+	}
+
+	received, err := getMetrics(handler, target, c.maxBodySize)
+	c.addBytesReceived(collector, received)
+	return err
+}
+
+// NodeStats 获取节点的 /_node/stats 统计信息
+func (c *APIClient) NodeStats() (NodeStatsResponse, error) {
+	var response NodeStatsResponse
+	err := c.get(nodeStatsCollectorName, "/_node/stats", &response)
+	return response, err
+}
+
+// NodeInfo 获取节点的 /_node 基本信息
+func (c *APIClient) NodeInfo() (NodeInfoResponse, error) {
+	var response NodeInfoResponse
+	err := c.get(nodeInfoCollectorName, "/_node", &response)
+	return response, err
+}
+
+// NodePlugins 获取节点的 /_node/plugins 插件列表
+func (c *APIClient) NodePlugins() (NodePluginsResponse, error) {
+	var response NodePluginsResponse
+	err := c.get(clusterCollectorName, "/_node/plugins", &response)
+	return response, err
+}
+
+// HotThreads 获取节点的 /_node/hot_threads 热点线程信息
+func (c *APIClient) HotThreads() (HotThreadsResponse, error) {
+	var response HotThreadsResponse
+	err := c.get(hotThreadsCollectorName, "/_node/hot_threads", &response)
+	return response, err
+}
+
+// HealthReport 获取节点的 /_health_report 健康报告
+func (c *APIClient) HealthReport() (HealthReportResponse, error) {
+	var response HealthReportResponse
+	err := c.get(healthReportCollectorName, "/_health_report", &response)
+	return response, err
+}
@@ -0,0 +1,336 @@
+package collector
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// nodeObservation 是集群成员在一次收集中的状态
+type nodeObservation struct {
+	up      bool         // 节点的所有子收集器都收集成功
+	summary *nodeSummary // 节点统计摘要，节点不可用或未启用节点统计时为空
+}
+
+// ClusterOptions 定义了集群收集器的参数
+type ClusterOptions struct {
+	Name string // 集群名称，作为 cluster 标签
+
+	// MinHealthy 是集群健康所需的最少可用节点数，小于等于 0 表示所有节点都必须可用
+	MinHealthy int
+
+	// Labels 是附加在集群指标上的常量标签，其中的 cluster 标签被集群名称覆盖
+	Labels map[string]string
+
+	// Previous 是重载前的同名集群收集器，新的收集器延续它的事件总数
+	Previous *ClusterCollector
+}
+
+// ClusterCollector 汇总一组相同配置的 Logstash 节点，导出集群级别的可用节点数、健康状态、pipeline 事件总数
+// 以及节点之间的配置漂移。成员仍然各自注册并导出节点级指标，集群收集使用成员最近一次抓取的结果
+type ClusterCollector struct {
+	name       string
+	members    []*LogstashCollector
+	minHealthy int
+	configs    configCache  // 成员节点的 pipeline 设置和插件版本
+	events     *eventTotals // 成员 pipeline 事件数的累加值
+
+	nodesConfiguredDesc *prometheus.Desc
+	nodesUpDesc         *prometheus.Desc
+	minHealthyDesc      *prometheus.Desc
+	healthyDesc         *prometheus.Desc
+	pipelineNodesDesc   *prometheus.Desc
+	eventsInDesc        *prometheus.Desc
+	eventsFilteredDesc  *prometheus.Desc
+	eventsOutDesc       *prometheus.Desc
+
+	driftDesc                    *prometheus.Desc
+	versionVariantsDesc          *prometheus.Desc
+	pipelineHashVariantsDesc     *prometheus.Desc
+	pipelineSettingsVariantsDesc *prometheus.Desc
+	pluginVersionVariantsDesc    *prometheus.Desc
+}
+
+// NewClusterCollector 创建集群收集器，members 为空或标签不合法时返回错误
+func NewClusterCollector(members []*LogstashCollector, opts ClusterOptions) (*ClusterCollector, error) {
+	if opts.Name == "" {
+		return nil, fmt.Errorf("集群名称不能为空")
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("集群 %s 没有成员", opts.Name)
+	}
+
+	// 集群指标的 cluster 标签总是集群名称，全局 labels 中的同名标签只作用于节点级指标
+	labels := make(map[string]string, len(opts.Labels))
+	for name, value := range opts.Labels {
+		if name != "cluster" {
+			labels[name] = value
+		}
+	}
+	constLabels, err := endpointLabels("cluster", opts.Name, labels)
+	if err != nil {
+		return nil, err
+	}
+
+	events := newEventTotals()
+	if opts.Previous != nil {
+		events = opts.Previous.events
+	}
+
+	minHealthy := opts.MinHealthy
+	if minHealthy <= 0 || minHealthy > len(members) {
+		minHealthy = len(members)
+	}
+
+	const subsystem = "cluster"
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(Namespace, subsystem, name), help, labels, constLabels)
+	}
+
+	return &ClusterCollector{
+		name:       opts.Name,
+		members:    members,
+		minHealthy: minHealthy,
+		events:     events,
+
+		nodesConfiguredDesc: desc("nodes_configured", "Number of Logstash nodes configured in the cluster."),
+		nodesUpDesc:         desc("nodes_up", "Number of Logstash nodes in the cluster whose last collection succeeded."),
+		minHealthyDesc:      desc("min_healthy_nodes", "Minimum number of available nodes for the cluster to be healthy."),
+		healthyDesc:         desc("healthy", "Whether at least min_healthy_nodes nodes of the cluster are available."),
+		pipelineNodesDesc:   desc("pipeline_nodes", "Number of available nodes running the pipeline.", "pipeline"),
+		eventsInDesc:        desc("pipeline_events_in_total", "Number of events received by the pipeline across the nodes of the cluster.", "pipeline"),
+		eventsFilteredDesc:  desc("pipeline_events_filtered_total", "Number of events filtered by the pipeline across the nodes of the cluster.", "pipeline"),
+		eventsOutDesc:       desc("pipeline_events_out_total", "Number of events emitted by the pipeline across the nodes of the cluster.", "pipeline"),
+
+		driftDesc:                    desc("config_drift", "Whether the available nodes of the cluster differ in version, pipelines, pipeline settings or plugin versions."),
+		versionVariantsDesc:          desc("version_variants", "Number of distinct Logstash versions across available nodes."),
+		pipelineHashVariantsDesc:     desc("pipeline_hash_variants", "Number of distinct pipeline config hashes across available nodes, counting nodes without the pipeline as one variant.", "pipeline"),
+		pipelineSettingsVariantsDesc: desc("pipeline_settings_variants", "Number of distinct pipeline settings across available nodes, counting nodes without the pipeline as one variant.", "pipeline"),
+		pluginVersionVariantsDesc:    desc("plugin_version_variants", "Number of distinct plugin versions across available nodes, counting nodes without the plugin as one variant.", "plugin"),
+	}, nil
+}
+
+// Describe 实现了 prometheus.Collector 接口
+func (c *ClusterCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.nodesConfiguredDesc
+	ch <- c.nodesUpDesc
+	ch <- c.minHealthyDesc
+	ch <- c.healthyDesc
+	ch <- c.pipelineNodesDesc
+	ch <- c.eventsInDesc
+	ch <- c.eventsFilteredDesc
+	ch <- c.eventsOutDesc
+	ch <- c.driftDesc
+	ch <- c.versionVariantsDesc
+	ch <- c.pipelineHashVariantsDesc
+	ch <- c.pipelineSettingsVariantsDesc
+	ch <- c.pluginVersionVariantsDesc
+}
+
+// clusterSurvey 是对集群所有成员的一次观察
+type clusterSurvey struct {
+	observations []nodeObservation
+	configs      []*nodeConfig // 可用成员的配置，无法获取时为空
+}
+
+// survey 并发观察所有成员，并获取可用成员用于漂移比较的配置
+func (c *ClusterCollector) survey() clusterSurvey {
+	result := clusterSurvey{
+		observations: make([]nodeObservation, len(c.members)),
+		configs:      make([]*nodeConfig, len(c.members)),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(c.members))
+	for i, member := range c.members {
+		go func(i int, member *LogstashCollector) {
+			defer wg.Done()
+			obs := member.observe()
+			result.observations[i] = obs
+			if !obs.up || obs.summary == nil {
+				return
+			}
+
+			config, err := c.configs.get(member, obs.summary)
+			if err != nil {
+				Errorf("集群 %s 获取节点 %s 的配置失败: %v", c.name, member.Instance(), err)
+				return
+			}
+			result.configs[i] = config
+		}(i, member)
+	}
+	wg.Wait()
+
+	return result
+}
+
+// compare 比较获取到配置的成员，返回参与比较的节点、未参与比较的节点和属性分布
+func (c *ClusterCollector) compare(s clusterSurvey) ([]string, []string, []DriftAttribute) {
+	var instances, unreported []string
+	var configs []*nodeConfig
+	for i, config := range s.configs {
+		if config == nil {
+			unreported = append(unreported, c.members[i].Instance())
+			continue
+		}
+		instances = append(instances, c.members[i].Instance())
+		configs = append(configs, config)
+	}
+	if len(configs) == 0 {
+		return instances, unreported, nil
+	}
+	return instances, unreported, compareConfigs(instances, configs)
+}
+
+// DriftReport 观察所有成员并返回配置漂移报告，用于找出与其他节点不一致的节点
+func (c *ClusterCollector) DriftReport() DriftReport {
+	instances, unreported, attributes := c.compare(c.survey())
+
+	report := DriftReport{
+		Cluster:    c.name,
+		Nodes:      append([]string{}, instances...),
+		Unreported: append([]string{}, unreported...),
+		Attributes: append([]DriftAttribute{}, attributes...),
+	}
+	for _, attribute := range attributes {
+		if len(attribute.Variants) > 1 {
+			report.Drift = true
+		}
+	}
+	return report
+}
+
+// Name 返回集群名称
+func (c *ClusterCollector) Name() string {
+	return c.name
+}
+
+// Collect 实现了 prometheus.Collector 接口，并发观察所有成员后汇总
+// pipeline 事件总数按成员的增量累加，节点不可用、重启或离开集群时不会下降
+func (c *ClusterCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.survey()
+
+	up := 0
+	nodes := map[string]int{}
+	for i, obs := range s.observations {
+		if !obs.up {
+			continue
+		}
+		up++
+		if obs.summary == nil {
+			continue
+		}
+		for id := range obs.summary.pipelines {
+			nodes[id]++
+		}
+		c.events.add(c.members[i].Instance(), obs.summary)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.nodesConfiguredDesc, prometheus.GaugeValue, float64(len(c.members)))
+	ch <- prometheus.MustNewConstMetric(c.nodesUpDesc, prometheus.GaugeValue, float64(up))
+	ch <- prometheus.MustNewConstMetric(c.minHealthyDesc, prometheus.GaugeValue, float64(c.minHealthy))
+	ch <- prometheus.MustNewConstMetric(c.healthyDesc, prometheus.GaugeValue, boolToFloat(up >= c.minHealthy))
+
+	ids := make([]string, 0, len(nodes))
+	for id := range nodes {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		ch <- prometheus.MustNewConstMetric(c.pipelineNodesDesc, prometheus.GaugeValue, float64(nodes[id]), id)
+	}
+
+	totals := c.events.snapshot()
+	ids = ids[:0]
+	for id := range totals {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		total := totals[id]
+		ch <- prometheus.MustNewConstMetric(c.eventsInDesc, prometheus.CounterValue, float64(total.In), id)
+		ch <- prometheus.MustNewConstMetric(c.eventsFilteredDesc, prometheus.CounterValue, float64(total.Filtered), id)
+		ch <- prometheus.MustNewConstMetric(c.eventsOutDesc, prometheus.CounterValue, float64(total.Out), id)
+	}
+
+	c.collectDrift(ch, s)
+}
+
+// collectDrift 发送配置漂移指标，没有任何成员的配置可用时不发送
+func (c *ClusterCollector) collectDrift(ch chan<- prometheus.Metric, s clusterSurvey) {
+	_, _, attributes := c.compare(s)
+	if len(attributes) == 0 {
+		return
+	}
+
+	descs := map[string]*prometheus.Desc{
+		DriftPipelineHash:     c.pipelineHashVariantsDesc,
+		DriftPipelineSettings: c.pipelineSettingsVariantsDesc,
+		DriftPluginVersion:    c.pluginVersionVariantsDesc,
+	}
+
+	drift := false
+	for _, attribute := range attributes {
+		variants := float64(len(attribute.Variants))
+		if variants > 1 {
+			drift = true
+		}
+		if attribute.Kind == DriftVersion {
+			ch <- prometheus.MustNewConstMetric(c.versionVariantsDesc, prometheus.GaugeValue, variants)
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(descs[attribute.Kind], prometheus.GaugeValue, variants, attribute.Key)
+	}
+	ch <- prometheus.MustNewConstMetric(c.driftDesc, prometheus.GaugeValue, boolToFloat(drift))
+}
+
+// eventTotals 累加集群成员的 pipeline 事件数，保证集群总数单调不减：
+// 记录每个成员每个 pipeline 最近一次的计数，只累加两次观察之间的增量；计数变小或 pipeline 重建时
+// 视为从 0 重新计数。成员不可用或离开集群时已累加的部分保留在总数中
+type eventTotals struct {
+	mu     sync.Mutex
+	last   map[string]map[string]pipelineSummary // 成员 → pipeline → 最近一次观察到的摘要
+	totals map[string]pipelineEvents
+}
+
+// newEventTotals 创建空的事件累加器
+func newEventTotals() *eventTotals {
+	return &eventTotals{
+		last:   map[string]map[string]pipelineSummary{},
+		totals: map[string]pipelineEvents{},
+	}
+}
+
+// add 累加成员 instance 自上次观察以来的事件数
+func (t *eventTotals) add(instance string, summary *nodeSummary) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	last := t.last[instance]
+	for id, pipeline := range summary.pipelines {
+		delta := pipeline.events
+		if prev, ok := last[id]; ok && prev.ephemeralID == pipeline.ephemeralID {
+			delta = pipeline.events.since(prev.events)
+		}
+		total := t.totals[id]
+		total.In += delta.In
+		total.Filtered += delta.Filtered
+		total.Out += delta.Out
+		t.totals[id] = total
+	}
+	t.last[instance] = summary.pipelines
+}
+
+// snapshot 返回当前的事件总数
+func (t *eventTotals) snapshot() map[string]pipelineEvents {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	totals := make(map[string]pipelineEvents, len(t.totals))
+	for id, total := range t.totals {
+		totals[id] = total
+	}
+	return totals
+}
@@ -0,0 +1,199 @@
+package collector
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"go-logstash-exporter/pkg/logstashtest"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// gaugeValue 返回指标族中第一个序列的值，指标族不存在时返回 -1
+func gaugeValue(families []*dto.MetricFamily, name string) float64 {
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	return -1
+}
+
+// counterValue 返回指标族中 pipeline 标签为 pipeline 的计数器的值，不存在时返回 -1
+func counterValue(families []*dto.MetricFamily, name, pipeline string) float64 {
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "pipeline" && label.GetValue() == pipeline {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+	}
+	return -1
+}
+
+// nodeStatsBody 返回修改了 main pipeline 事件数和临时标识的 /_node/stats 响应
+func nodeStatsBody(t *testing.T, events int, ephemeralID string) []byte {
+	t.Helper()
+	body, _ := logstashtest.Fixture(logstashtest.Version8, logstashtest.PathNodeStats)
+	var stats map[string]interface{}
+	if err := json.Unmarshal(body, &stats); err != nil {
+		t.Fatal(err)
+	}
+	main := stats["pipelines"].(map[string]interface{})["main"].(map[string]interface{})
+	main["ephemeral_id"] = ephemeralID
+	main["events"] = map[string]interface{}{"in": events, "filtered": events, "out": events}
+	body, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+// newTestCluster 为每个服务器创建成员收集器，与集群收集器一起注册到新的 registry
+func newTestCluster(t *testing.T, servers []*logstashtest.Server, opts ClusterOptions) (*prometheus.Registry, *ClusterCollector) {
+	t.Helper()
+	registry := prometheus.NewRegistry()
+	var members []*LogstashCollector
+	for _, server := range servers {
+		memberOpts := DefaultOptions()
+		memberOpts.Retry.MaxAttempts = 1
+		memberOpts.Collectors = map[string]bool{"node_stats": true}
+		member, err := NewWithOptions(server.URL, memberOpts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(member.Close)
+		registry.MustRegister(member)
+		members = append(members, member)
+	}
+
+	cluster, err := NewClusterCollector(members, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry.MustRegister(cluster)
+	return registry, cluster
+}
+
+// 集群事件总数按成员的增量累加，节点不可用、恢复和重启时都不会下降
+func TestClusterEventTotals(t *testing.T) {
+	servers := []*logstashtest.Server{
+		logstashtest.NewTestServer(t, logstashtest.Version8, logstashtest.WithBody(logstashtest.PathNodeStats, nodeStatsBody(t, 100, "a1"))),
+		logstashtest.NewTestServer(t, logstashtest.Version8, logstashtest.WithBody(logstashtest.PathNodeStats, nodeStatsBody(t, 50, "b1"))),
+	}
+	registry, cluster := newTestCluster(t, servers, ClusterOptions{Name: "ingest", MinHealthy: 1})
+
+	// 集群收集可能晚一个抓取周期看到节点的状态，每一步抓取两次
+	total := func() float64 {
+		registry.Gather()
+		families, _ := registry.Gather()
+		return counterValue(families, "logstash_cluster_pipeline_events_in_total", "main")
+	}
+
+	steps := []struct {
+		name  string
+		setup func()
+		want  float64
+	}{
+		{"初始值", func() {}, 150},
+		{"节点 a 增长", func() { servers[0].SetBody(logstashtest.PathNodeStats, nodeStatsBody(t, 130, "a1")) }, 180},
+		{"节点 b 不可用", func() { servers[1].SetFault(logstashtest.Fault{StatusCode: http.StatusInternalServerError}) }, 180},
+		{"节点 b 恢复", func() {
+			servers[1].SetFault(logstashtest.Fault{})
+			servers[1].SetBody(logstashtest.PathNodeStats, nodeStatsBody(t, 70, "b1"))
+		}, 200},
+		{"节点 a 重启", func() { servers[0].SetBody(logstashtest.PathNodeStats, nodeStatsBody(t, 10, "a2")) }, 210},
+		{"节点 b 计数变小", func() { servers[1].SetBody(logstashtest.PathNodeStats, nodeStatsBody(t, 5, "b1")) }, 215},
+	}
+	for _, step := range steps {
+		step.setup()
+		if got := total(); got != step.want {
+			t.Fatalf("%s: events_in_total = %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	// 重载后的同名集群延续之前的总数
+	registry.Unregister(cluster)
+	reloaded, err := NewClusterCollector(cluster.members, ClusterOptions{Name: "ingest", Previous: cluster})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry.MustRegister(reloaded)
+	if got := total(); got != 215 {
+		t.Errorf("重载后 events_in_total = %v, want 215", got)
+	}
+}
+
+// 全局 labels 中的 cluster 标签不影响集群收集器，集群指标的 cluster 标签取集群名称
+func TestClusterLabelOverride(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8)
+	registry, _ := newTestCluster(t, []*logstashtest.Server{server}, ClusterOptions{
+		Name:   "ingest",
+		Labels: map[string]string{"cluster": "prod", "env": "test"},
+	})
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "logstash_cluster_nodes_configured" {
+			continue
+		}
+		labels := map[string]string{}
+		for _, label := range family.GetMetric()[0].GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		if labels["cluster"] != "ingest" || labels["env"] != "test" {
+			t.Errorf("labels = %v, want cluster=ingest env=test", labels)
+		}
+		return
+	}
+	t.Error("没有导出 logstash_cluster_nodes_configured")
+}
+
+func TestClusterCollectorUsesMemberScrape(t *testing.T) {
+	servers := []*logstashtest.Server{
+		logstashtest.NewTestServer(t, logstashtest.Version8),
+		logstashtest.NewTestServer(t, logstashtest.Version8),
+	}
+
+	registry, _ := newTestCluster(t, servers, ClusterOptions{Name: "ingest", MinHealthy: 1})
+
+	if _, err := registry.Gather(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 之后的每次抓取每个节点只请求一次 /_node/stats
+	for i := 0; i < 3; i++ {
+		before := servers[0].Requests(logstashtest.PathNodeStats)
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := servers[0].Requests(logstashtest.PathNodeStats) - before; got != 1 {
+			t.Errorf("一次抓取请求了 %d 次 /_node/stats，want 1", got)
+		}
+		if got := gaugeValue(families, "logstash_cluster_nodes_up"); got != 2 {
+			t.Errorf("nodes_up = %v, want 2", got)
+		}
+	}
+
+	// 节点不可用后集群仍然健康，集群收集可能晚一个抓取周期看到节点的状态
+	servers[1].SetFault(logstashtest.Fault{StatusCode: http.StatusInternalServerError})
+	registry.Gather()
+	families, _ := registry.Gather()
+	if got := gaugeValue(families, "logstash_cluster_nodes_up"); got != 1 {
+		t.Errorf("nodes_up = %v, want 1", got)
+	}
+	if got := gaugeValue(families, "logstash_cluster_healthy"); got != 1 {
+		t.Errorf("healthy = %v, want 1", got)
+	}
+}
@@ -1,8 +1,12 @@
 package collector
 
 import (
+	"fmt"
 	"net/url"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -13,35 +17,149 @@ const (
 	Namespace = "logstash"
 )
 
-// 定义全局指标：抓取持续时间统计
-var (
-	scrapeDurations = prometheus.NewSummaryVec(
-		prometheus.SummaryOpts{
-			Namespace: Namespace,
-			Subsystem: "exporter",
-			Name:      "scrape_duration_seconds",
-			Help:      "logstash_exporter: 抓取任务的持续时间统计。",
-		},
-		[]string{"collector", "result", "instance"},
-	)
+// DefaultInstanceLabel 是标识 Logstash 节点的默认标签名
+const DefaultInstanceLabel = "instance"
+
+// 子收集器名称，用作 collector 标签的取值
+const (
+	nodeStatsCollectorName    = "node"          // 节点统计信息收集器
+	nodeInfoCollectorName     = "info"          // 节点基本信息收集器
+	hotThreadsCollectorName   = "hot_threads"   // 热点线程收集器
+	healthReportCollectorName = "health_report" // 健康报告收集器
+	clusterCollectorName      = "cluster"       // 集群收集器（配置漂移检测）
+)
+
+// 子收集器的配置键名，对应配置项 collectors.<name>
+const (
+	CollectorNodeStats    = "node_stats"
+	CollectorInfo         = "info"
+	CollectorHotThreads   = "hot_threads"
+	CollectorHealthReport = "health_report"
 )
 
+// collectorEnv 是创建子收集器时共享的端点级依赖
+type collectorEnv struct {
+	client      *APIClient        // 同一端点共享的 API 客户端
+	constLabels prometheus.Labels // 所有 Desc 的常量标签（实例标识）
+	cache       *nodeInfoCache    // 节点信息缓存，可以为空
+	filter      *seriesFilter     // pipeline 和插件过滤规则，可以为空
+	maxSeries   int               // 单个插件指标的序列上限，小于等于 0 表示不限制
+}
+
+// newCollectorEnv 为独立使用的子收集器创建默认依赖
+func newCollectorEnv(endpoint, instance string) (*collectorEnv, error) {
+	client, err := NewAPIClient(endpoint, DefaultOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	return &collectorEnv{
+		client:      client,
+		constLabels: prometheus.Labels{DefaultInstanceLabel: instance},
+	}, nil
+}
+
+// subCollector 描述一个可开关的子收集器
+type subCollector struct {
+	name    string // collector 标签取值
+	enabled bool   // 默认是否启用
+	factory func(env *collectorEnv) (Collector, error)
+}
+
+// subCollectors 是所有可开关的子收集器，键为配置键名
+// 开销较大或依赖较新 Logstash API 的子收集器默认关闭
+var subCollectors = map[string]subCollector{
+	CollectorNodeStats:    {nodeStatsCollectorName, true, newNodeStatsCollector},
+	CollectorInfo:         {nodeInfoCollectorName, true, newNodeInfoCollector},
+	CollectorHotThreads:   {hotThreadsCollectorName, false, newHotThreadsCollector},
+	CollectorHealthReport: {healthReportCollectorName, false, newHealthReportCollector},
+}
+
+// CollectorNames 返回所有可开关子收集器的配置键名（已排序）
+func CollectorNames() []string {
+	names := make([]string, 0, len(subCollectors))
+	for name := range subCollectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DefaultCollectors 返回子收集器的默认开关
+func DefaultCollectors() map[string]bool {
+	enabled := make(map[string]bool, len(subCollectors))
+	for name, sc := range subCollectors {
+		enabled[name] = sc.enabled
+	}
+	return enabled
+}
+
+// enabledCollectors 合并默认开关和配置的开关，配置中的未知名称视为错误
+func enabledCollectors(selection map[string]bool) (map[string]bool, error) {
+	enabled := DefaultCollectors()
+	for name, on := range selection {
+		if _, ok := subCollectors[name]; !ok {
+			return nil, fmt.Errorf("未知的子收集器: %s（可用: %s）", name, strings.Join(CollectorNames(), ", "))
+		}
+		enabled[name] = on
+	}
+	return enabled, nil
+}
+
 // Collector 接口定义了指标收集器的基本行为
 type Collector interface {
+	// Describe 方法用于发送收集器可能产生的所有指标的 Desc
+	Describe(ch chan<- *prometheus.Desc)
 	// Collect 方法用于收集指标并通过 channel 发送
 	Collect(ch chan<- prometheus.Metric) error
 }
 
 // LogstashCollector 是主收集器，负责管理所有子收集器
+// 所有 Desc 都以常量标签的形式携带实例标识，不同端点的 Desc 互不冲突，
+// 因此同一个 registry 可以注册多个端点并完整地校验它们
 type LogstashCollector struct {
 	collectors map[string]Collector // 子收集器映射表
 	endpoint   string               // Logstash API 端点
 	instance   string               // Logstash 实例标识
+	client     *APIClient           // 子收集器共享的 API 客户端
+	cache      *nodeInfoCache       // 子收集器共享的节点信息缓存，可以为空
+
+	scrapeDurations   *prometheus.SummaryVec // 抓取持续时间统计
+	breakerStateDesc  *prometheus.Desc       // 端点熔断器状态
+	bytesReceivedDesc *prometheus.Desc       // API 响应接收字节数
+
+	poller *poller     // 后台轮询器，为空时每次抓取同步请求 Logstash
+	pool   *WorkerPool // 全局工作池，为空时不限制并发
+
+	succeeded atomic.Bool // 最近一次同步收集中所有子收集器是否都成功
+
+	flight        *flightGroup     // 合并并发的同步收集
+	coalesced     atomic.Int64     // 被合并的收集请求数
+	coalescedDesc *prometheus.Desc // 被合并的收集请求数
 }
 
-// New 创建一个新的 LogstashCollector 实例
+// New 使用默认参数创建一个新的 LogstashCollector 实例
 func New(endpoint string) (*LogstashCollector, error) {
-	// 解析 endpoint URL 获取实例标识
+	return NewWithOptions(endpoint, DefaultOptions())
+}
+
+// NewWithOptions 使用指定参数创建一个新的 LogstashCollector 实例
+func NewWithOptions(endpoint string, opts Options) (*LogstashCollector, error) {
+	if err := opts.Transport.validate(); err != nil {
+		return nil, err
+	}
+
+	enabled, err := enabledCollectors(opts.Collectors)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := newSeriesFilter(opts.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	// 解析 endpoint URL 获取实例标识，设置了名称时使用名称
 	u, err := url.Parse(endpoint)
 	if err != nil {
 		return nil, err
@@ -50,62 +168,331 @@ func New(endpoint string) (*LogstashCollector, error) {
 	if instance == "" {
 		instance = endpoint
 	}
+	if opts.Name != "" {
+		instance = opts.Name
+	}
 
-	// 创建节点统计信息收集器
-	nodeStats, err := NewNodeStatsCollector(endpoint, instance)
+	// 端点的常量标签附加在该端点的所有 Desc 上
+	constLabels, err := endpointLabels(opts.InstanceLabel, instance, opts.Labels)
 	if err != nil {
 		return nil, err
 	}
 
-	// 创建节点基本信息收集器
-	nodeInfo, err := NewNodeInfoCollector(endpoint, instance)
+	// 同一端点的子收集器共享 API 客户端和熔断器
+	client, err := NewAPIClient(endpoint, opts)
 	if err != nil {
 		return nil, err
 	}
 
+	env := &collectorEnv{
+		client:      client,
+		constLabels: constLabels,
+		// 节点信息缓存由节点统计和节点信息收集器共享：节点统计负责在重启或重载时使其失效
+		cache:     newNodeInfoCache(opts.NodeInfoTTL),
+		filter:    filter,
+		maxSeries: opts.MaxSeriesPerMetric,
+	}
+
+	// 只创建启用的子收集器
+	collectors := make(map[string]Collector)
+	for key, sc := range subCollectors {
+		if !enabled[key] {
+			continue
+		}
+		collector, err := sc.factory(env)
+		if err != nil {
+			client.Close()
+			return nil, err
+		}
+		collectors[sc.name] = collector
+	}
+
 	// 返回配置好的收集器实例
-	return &LogstashCollector{
-		endpoint: endpoint,
-		instance: instance,
-		collectors: map[string]Collector{
-			"node": nodeStats, // 节点统计信息收集器
-			"info": nodeInfo,  // 节点基本信息收集器
-		},
-	}, nil
+	c := &LogstashCollector{
+		endpoint:   endpoint,
+		instance:   instance,
+		client:     client,
+		cache:      env.cache,
+		collectors: collectors,
+		pool:       opts.Pool,
+
+		scrapeDurations: prometheus.NewSummaryVec(
+			prometheus.SummaryOpts{
+				Namespace:   Namespace,
+				Subsystem:   "exporter",
+				Name:        "scrape_duration_seconds",
+				Help:        "logstash_exporter: 抓取任务的持续时间统计。",
+				ConstLabels: constLabels,
+			},
+			[]string{"collector", "result"},
+		),
+
+		// 端点熔断器状态：0 关闭，1 打开，2 半开
+		breakerStateDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "exporter", "circuit_breaker_state"),
+			"logstash_exporter: 端点熔断器状态（0 关闭，1 打开，2 半开）。",
+			nil,
+			constLabels,
+		),
+
+		// 按子收集器统计的 API 响应接收字节数
+		bytesReceivedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "exporter", "response_bytes_total"),
+			"logstash_exporter: 从 Logstash API 接收的响应字节数（压缩后）。",
+			[]string{"collector"},
+			constLabels,
+		),
+	}
+
+	c.flight = newFlightGroup(opts.MinRefreshInterval)
+	c.coalescedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, "exporter", "coalesced_requests_total"),
+		"logstash_exporter: 与其他抓取共享同一次 Logstash 请求、或在最小刷新间隔内复用结果的抓取次数。",
+		nil,
+		constLabels,
+	)
+
+	// 配置了轮询间隔时使用后台轮询模式，需要调用 Start 启动
+	if opts.Poll.Interval > 0 {
+		c.poller = newPoller(c, opts.Poll, constLabels)
+	}
+
+	// 严格模式下在创建时检查子收集器之间的 Desc 冲突
+	if opts.Strict {
+		if err := c.validateDescs(); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// endpointLabels 合并实例标签和配置的常量标签，标签名不合法或与实例标签冲突时返回错误
+func endpointLabels(instanceLabel, instance string, labels map[string]string) (prometheus.Labels, error) {
+	if instanceLabel == "" {
+		instanceLabel = DefaultInstanceLabel
+	}
+	if !labelNameRE.MatchString(instanceLabel) || strings.HasPrefix(instanceLabel, "__") {
+		return nil, fmt.Errorf("实例标签名 %q 不合法", instanceLabel)
+	}
+
+	constLabels := prometheus.Labels{instanceLabel: instance}
+	for name, value := range labels {
+		if !labelNameRE.MatchString(name) || strings.HasPrefix(name, "__") {
+			return nil, fmt.Errorf("常量标签名 %q 不合法", name)
+		}
+		if _, ok := constLabels[name]; ok {
+			return nil, fmt.Errorf("常量标签 %s 与实例标签冲突", name)
+		}
+		constLabels[name] = value
+	}
+	return constLabels, nil
+}
+
+// Instance 返回收集器的 Logstash 实例标识
+func (c *LogstashCollector) Instance() string {
+	return c.instance
+}
+
+// Start 在后台轮询模式下启动轮询，同步模式下不做任何事
+func (c *LogstashCollector) Start() {
+	if c.poller != nil {
+		c.poller.start()
+	}
+}
+
+// Close 停止后台轮询并等待正在进行的轮询结束，然后释放收集器自行创建的传输中的空闲连接
+// 没有调用过 Start 的收集器也可以关闭
+func (c *LogstashCollector) Close() {
+	if c.poller != nil {
+		c.poller.stop()
+	}
+	c.client.Close()
 }
 
 // Describe 实现了 prometheus.Collector 接口，用于描述所有可能的指标
 func (c *LogstashCollector) Describe(ch chan<- *prometheus.Desc) {
-	scrapeDurations.Describe(ch)
+	c.scrapeDurations.Describe(ch)
+	ch <- c.breakerStateDesc
+	ch <- c.bytesReceivedDesc
+	ch <- c.coalescedDesc
+	if c.poller != nil {
+		c.poller.describe(ch)
+	}
+
+	for _, collector := range c.collectors {
+		collector.Describe(ch)
+	}
+}
+
+// validateDescs 检查子收集器和自身指标之间是否存在相同名称和常量标签的 Desc
+// registry 允许同一个收集器内出现重复的 Desc，因此需要在这里单独检查
+func (c *LogstashCollector) validateDescs() error {
+	ch := make(chan *prometheus.Desc)
+	go func() {
+		c.Describe(ch)
+		close(ch)
+	}()
+
+	seen := map[string]bool{}
+	var err error
+	for desc := range ch {
+		key := desc.String()
+		if seen[key] && err == nil {
+			err = fmt.Errorf("端点 %s 的指标描述重复: %s", c.endpoint, key)
+		}
+		seen[key] = true
+	}
+
+	return err
 }
 
 // Collect 实现了 prometheus.Collector 接口，用于收集当前的指标值
+// 后台轮询模式下发送最近一次成功轮询的快照，否则同步请求 Logstash
 func (c *LogstashCollector) Collect(ch chan<- prometheus.Metric) {
+	if c.poller != nil {
+		c.poller.collect(ch)
+	} else {
+		c.collectSync(ch)
+	}
+
+	// 收集抓取持续时间指标
+	c.scrapeDurations.Collect(ch)
+
+	// 收集熔断器状态指标
+	ch <- prometheus.MustNewConstMetric(
+		c.breakerStateDesc,
+		prometheus.GaugeValue,
+		float64(c.client.BreakerState()),
+	)
+
+	// 收集合并请求数指标
+	ch <- prometheus.MustNewConstMetric(
+		c.coalescedDesc,
+		prometheus.CounterValue,
+		float64(c.coalesced.Load()),
+	)
+
+	// 收集响应字节数指标
+	for name, n := range c.client.BytesReceived() {
+		ch <- prometheus.MustNewConstMetric(
+			c.bytesReceivedDesc,
+			prometheus.CounterValue,
+			float64(n),
+			name,
+		)
+	}
+}
+
+// Succeeded 返回最近一次收集是否成功：同步模式下所有子收集器都没有出错，
+// 后台轮询模式下所有子收集器都有未过期的快照
+func (c *LogstashCollector) Succeeded() bool {
+	if c.poller != nil {
+		return c.poller.fresh()
+	}
+	return c.succeeded.Load()
+}
+
+// observe 返回节点最近一次收集的状态，供集群聚合使用
+// 同步模式下使用成员自身抓取的结果：等待正在进行的收集，但不额外请求 Logstash，只有从未收集过时才收集一次；
+// 后台轮询模式下直接使用轮询结果
+func (c *LogstashCollector) observe() nodeObservation {
+	if c.poller == nil {
+		c.flight.wait(c.gather)
+	}
+
+	obs := nodeObservation{up: c.Succeeded()}
+	if !obs.up {
+		return obs
+	}
+	if ns, ok := c.collectors[nodeStatsCollectorName].(*NodeStatsCollector); ok {
+		obs.summary = ns.lastSummary()
+	}
+	return obs
+}
+
+// collectSync 同步收集所有子收集器的指标并发送到 ch
+// 并发的抓取合并为一次收集，共享同一份结果
+func (c *LogstashCollector) collectSync(ch chan<- prometheus.Metric) {
+	metrics, shared, err := c.flight.do(c.gather)
+	if shared {
+		c.coalesced.Add(1)
+	}
+	if err != nil {
+		Errorf("收集 %s 失败: %v", c.instance, err)
+		c.succeeded.Store(false)
+	}
+
+	for _, m := range metrics {
+		ch <- m
+	}
+}
+
+// gather 并发执行所有子收集器，返回它们产生的全部指标
+func (c *LogstashCollector) gather() []prometheus.Metric {
 	wg := sync.WaitGroup{}
 	wg.Add(len(c.collectors))
 
-	// 并发收集所有子收集器的指标
+	var mu sync.Mutex
+	var all []prometheus.Metric
+	var failed atomic.Bool
 	for name, collector := range c.collectors {
-		go func(name string, c Collector, instance string) {
-			begin := time.Now()
-			err := c.Collect(ch)
-			duration := time.Since(begin)
-
-			// 记录收集结果
-			result := "success"
+		go func(name string, collector Collector) {
+			defer wg.Done()
+			metrics, err := c.scrape(name, collector)
 			if err != nil {
-				result = "error"
+				failed.Store(true)
 			}
 
-			// 更新抓取持续时间指标
-			scrapeDurations.WithLabelValues(name, result, instance).Observe(duration.Seconds())
-			wg.Done()
-		}(name, collector, c.instance)
+			mu.Lock()
+			all = append(all, metrics...)
+			mu.Unlock()
+		}(name, collector)
 	}
 
 	// 等待所有收集器完成
 	wg.Wait()
+	c.succeeded.Store(!failed.Load())
+	return all
+}
 
-	// 收集抓取持续时间指标
-	scrapeDurations.Collect(ch)
-}
\ No newline at end of file
+// scrape 执行单个子收集器并返回它产生的指标，同时记录抓取持续时间
+// 子收集器出错时仍返回已经产生的指标
+func (c *LogstashCollector) scrape(name string, collector Collector) ([]prometheus.Metric, error) {
+	ch := make(chan prometheus.Metric)
+	done := make(chan struct{})
+
+	var metrics []prometheus.Metric
+	go func() {
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		close(done)
+	}()
+
+	// 在工作池中执行，同一时间请求 Logstash 的子收集器数量受全局并发限制
+	// 子收集器 panic 时按收集失败处理，不影响其他子收集器和端点
+	var err error
+	var duration time.Duration
+	panicErr := c.pool.Submit(c.endpoint, func() {
+		begin := time.Now()
+		defer func() { duration = time.Since(begin) }()
+		err = collector.Collect(ch)
+	})
+	if panicErr != nil {
+		err = fmt.Errorf("子收集器 %s %v", name, panicErr)
+	}
+	close(ch)
+	<-done
+
+	// 记录收集结果
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+
+	// 更新抓取持续时间指标
+	c.scrapeDurations.WithLabelValues(name, result).Observe(duration.Seconds())
+	return metrics, err
+}
@@ -0,0 +1,223 @@
+package collector
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-logstash-exporter/pkg/logstashtest"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Close 释放收集器自行创建的传输中的空闲连接
+func TestCollectorCloseReleasesConnections(t *testing.T) {
+	var closed atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := logstashtest.Fixture(logstashtest.Version8, r.URL.Path)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateClosed {
+			closed.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	opts := DefaultOptions()
+	// 设置 TLS 参数时收集器拥有独立的传输；http 地址不使用 TLS
+	opts.TLS = TLSConfig{InsecureSkipVerify: true}
+	opts.Collectors = map[string]bool{"node_stats": true}
+	c, err := NewWithOptions(server.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.client.NodeStats(); err != nil {
+		t.Fatal(err)
+	}
+	if closed.Load() != 0 {
+		t.Fatal("请求结束后连接应保持空闲")
+	}
+
+	c.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for closed.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if closed.Load() == 0 {
+		t.Error("Close 之后空闲连接没有关闭")
+	}
+}
+
+// 使用调用方传入的传输时，Close 不关闭该传输
+func TestCollectorCloseSharedTransport(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8)
+
+	transport, err := NewHTTPTransport(AuthConfig{}, TLSConfig{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CloseTransport(transport)
+
+	opts := DefaultOptions()
+	opts.HTTPTransport = transport
+	opts.Collectors = map[string]bool{"node_stats": true}
+	c, err := NewWithOptions(server.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.client.base != nil {
+		t.Error("收集器不应拥有调用方传入的传输")
+	}
+	c.Close()
+}
+
+// 所有子收集器发送的指标都在 Describe 中声明，pedantic registry 不报错
+func TestDescribeComplete(t *testing.T) {
+	for _, version := range []string{logstashtest.Version6, logstashtest.Version7, logstashtest.Version8} {
+		t.Run(version, func(t *testing.T) {
+			server := logstashtest.NewTestServer(t, version)
+
+			opts := DefaultOptions()
+			opts.Strict = true
+			opts.Collectors = map[string]bool{"node_stats": true, "info": true, "hot_threads": true, "health_report": true}
+			c, err := NewWithOptions(server.URL, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+
+			registry := prometheus.NewPedanticRegistry()
+			if err := registry.Register(c); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := registry.Gather(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// dupCollector 重复声明一个已有的 Desc
+type dupCollector struct {
+	desc *prometheus.Desc
+}
+
+func (d dupCollector) Describe(ch chan<- *prometheus.Desc) { ch <- d.desc }
+
+func (d dupCollector) Collect(ch chan<- prometheus.Metric) error { return nil }
+
+func TestValidateDescs(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8)
+
+	tests := []struct {
+		name    string
+		extra   func(c *LogstashCollector) Collector
+		wantErr bool
+	}{
+		{"没有重复", nil, false},
+		{"与自身指标重复", func(c *LogstashCollector) Collector { return dupCollector{c.breakerStateDesc} }, true},
+		{"与子收集器指标重复", func(c *LogstashCollector) Collector {
+			return dupCollector{c.collectors[nodeStatsCollectorName].(*NodeStatsCollector).metrics[0].desc}
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Collectors = map[string]bool{"node_stats": true, "info": true}
+			c, err := NewWithOptions(server.URL, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			if tt.extra != nil {
+				c.collectors["dup"] = tt.extra(c)
+			}
+
+			if err := c.validateDescs(); (err != nil) != tt.wantErr {
+				t.Errorf("validateDescs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEnabledCollectors(t *testing.T) {
+	tests := []struct {
+		name      string
+		selection map[string]bool
+		want      map[string]bool
+		wantErr   bool
+	}{
+		{"默认开关", nil, map[string]bool{"node_stats": true, "info": true, "hot_threads": false, "health_report": false}, false},
+		{"关闭默认启用的子收集器", map[string]bool{"info": false}, map[string]bool{"node_stats": true, "info": false, "hot_threads": false, "health_report": false}, false},
+		{"启用默认关闭的子收集器", map[string]bool{"hot_threads": true}, map[string]bool{"node_stats": true, "info": true, "hot_threads": true, "health_report": false}, false},
+		{"未知的子收集器", map[string]bool{"jvm": true}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := enabledCollectors(tt.selection)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("enabledCollectors() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("enabledCollectors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 只有启用的子收集器才会请求对应的 API
+func TestCollectorToggles(t *testing.T) {
+	paths := map[string]string{
+		"node_stats":    logstashtest.PathNodeStats,
+		"info":          logstashtest.PathNode,
+		"hot_threads":   logstashtest.PathHotThreads,
+		"health_report": logstashtest.PathHealthReport,
+	}
+
+	tests := []struct {
+		name      string
+		selection map[string]bool
+	}{
+		{"默认开关", nil},
+		{"只启用节点统计", map[string]bool{"info": false}},
+		{"启用所有子收集器", map[string]bool{"hot_threads": true, "health_report": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := logstashtest.NewTestServer(t, logstashtest.Version8)
+			opts := DefaultOptions()
+			opts.Collectors = tt.selection
+			c, err := NewWithOptions(server.URL, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(c)
+			if _, err := registry.Gather(); err != nil {
+				t.Fatal(err)
+			}
+
+			enabled, _ := enabledCollectors(tt.selection)
+			for name, path := range paths {
+				if requested := server.Requests(path) > 0; requested != enabled[name] {
+					t.Errorf("%s: 请求了 %s = %v, want %v", name, path, requested, enabled[name])
+				}
+			}
+		})
+	}
+}
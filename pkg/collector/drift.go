@@ -0,0 +1,217 @@
+package collector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 配置漂移检测比较的属性
+const (
+	DriftVersion          = "version"           // Logstash 版本
+	DriftPipelineHash     = "pipeline_hash"     // pipeline 配置 hash
+	DriftPipelineSettings = "pipeline_settings" // pipeline 的 workers、batch_size 等设置
+	DriftPluginVersion    = "plugin_version"    // 插件版本
+)
+
+// absentValue 表示节点上缺少该 pipeline 或插件
+const absentValue = "<absent>"
+
+// nodeConfig 是单个节点用于漂移比较的配置
+type nodeConfig struct {
+	version   string
+	hashes    map[string]string // pipeline ID 到配置 hash
+	settings  map[string]string // pipeline ID 到设置摘要
+	plugins   map[string]string // 插件名到版本
+	signature string            // 节点和 pipeline 的临时标识，变化时重新获取配置
+}
+
+// configCache 缓存成员节点的配置：/_node 和 /_node/plugins 只在节点重启或 pipeline 重载后重新请求
+type configCache struct {
+	mu      sync.Mutex
+	configs map[*LogstashCollector]*nodeConfig
+}
+
+// get 返回节点的配置，签名变化时重新请求，请求失败时返回错误
+func (c *configCache) get(member *LogstashCollector, summary *nodeSummary) (*nodeConfig, error) {
+	signature := summarySignature(summary)
+
+	c.mu.Lock()
+	cached := c.configs[member]
+	c.mu.Unlock()
+	if cached != nil && cached.signature == signature {
+		return cached, nil
+	}
+
+	config, err := member.fetchConfig(summary)
+	if err != nil {
+		return nil, err
+	}
+	config.signature = signature
+
+	c.mu.Lock()
+	if c.configs == nil {
+		c.configs = map[*LogstashCollector]*nodeConfig{}
+	}
+	c.configs[member] = config
+	c.mu.Unlock()
+	return config, nil
+}
+
+// summarySignature 由节点和各 pipeline 的临时标识及 hash 组成，重启或重载后变化
+func summarySignature(summary *nodeSummary) string {
+	ids := make([]string, 0, len(summary.pipelines))
+	for id := range summary.pipelines {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var b strings.Builder
+	b.WriteString(summary.ephemeralID)
+	for _, id := range ids {
+		p := summary.pipelines[id]
+		fmt.Fprintf(&b, "|%s:%s:%s", id, p.ephemeralID, p.hash)
+	}
+	return b.String()
+}
+
+// fetchConfig 请求节点的 pipeline 设置和插件版本，在工作池中执行
+// 节点信息优先从端点的节点信息缓存读取，避免每次漂移检测都额外请求 /_node
+func (c *LogstashCollector) fetchConfig(summary *nodeSummary) (*nodeConfig, error) {
+	var plugins NodePluginsResponse
+	var err error
+	info, cached := c.cache.get()
+	panicErr := c.pool.Submit(c.endpoint, func() {
+		if !cached {
+			if info, err = c.client.NodeInfo(); err != nil {
+				return
+			}
+			c.cache.set(info)
+		}
+		plugins, err = c.client.NodePlugins()
+	})
+	if panicErr != nil {
+		return nil, panicErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	config := &nodeConfig{
+		version:  summary.version,
+		hashes:   make(map[string]string, len(summary.pipelines)),
+		settings: make(map[string]string, len(summary.pipelines)),
+		plugins:  make(map[string]string, len(plugins.Plugins)),
+	}
+	for id, p := range summary.pipelines {
+		config.hashes[id] = p.hash
+		if s, ok := info.Pipelines[id]; ok {
+			config.settings[id] = fmt.Sprintf("workers=%d batch_size=%d batch_delay=%d dead_letter_queue=%t",
+				s.Workers, s.BatchSize, s.BatchDelay, s.DeadLetterQueueEnabled)
+		}
+	}
+	for _, p := range plugins.Plugins {
+		config.plugins[p.Name] = p.Version
+	}
+	return config, nil
+}
+
+// DriftVariant 是属性的一种取值以及取该值的节点
+type DriftVariant struct {
+	Value string   `json:"value"`
+	Nodes []string `json:"nodes"`
+}
+
+// DriftAttribute 是一个被比较的属性，例如某个 pipeline 的配置 hash
+type DriftAttribute struct {
+	Kind     string         `json:"kind"`          // 属性类型：version、pipeline_hash、pipeline_settings 或 plugin_version
+	Key      string         `json:"key,omitempty"` // pipeline ID 或插件名，version 为空
+	Variants []DriftVariant `json:"variants"`      // 按节点数从多到少排列
+	OddNodes []string       `json:"odd_nodes"`     // 不属于最多节点取值的节点
+}
+
+// DriftReport 是集群的配置漂移报告
+type DriftReport struct {
+	Cluster    string           `json:"cluster"`
+	Nodes      []string         `json:"nodes"`      // 参与比较的节点
+	Unreported []string         `json:"unreported"` // 不可用或无法获取配置的节点
+	Drift      bool             `json:"drift"`      // 是否存在任何漂移
+	Attributes []DriftAttribute `json:"attributes"` // 所有被比较的属性，按类型和键排序
+}
+
+// compareConfigs 比较各节点的配置，返回每个属性的取值分布
+// 只在部分节点上存在的 pipeline 和插件，其余节点记为 <absent>
+func compareConfigs(instances []string, configs []*nodeConfig) []DriftAttribute {
+	type key struct{ kind, key string }
+	values := map[key]map[string][]string{}
+	add := func(k key, value, instance string) {
+		if values[k] == nil {
+			values[k] = map[string][]string{}
+		}
+		values[k][value] = append(values[k][value], instance)
+	}
+
+	// 先收集所有节点出现过的键，缺少该键的节点记为 absent
+	keys := map[key]bool{{DriftVersion, ""}: true}
+	for _, config := range configs {
+		for id := range config.hashes {
+			keys[key{DriftPipelineHash, id}] = true
+		}
+		for id := range config.settings {
+			keys[key{DriftPipelineSettings, id}] = true
+		}
+		for name := range config.plugins {
+			keys[key{DriftPluginVersion, name}] = true
+		}
+	}
+
+	for i, config := range configs {
+		for k := range keys {
+			var value string
+			var ok bool
+			switch k.kind {
+			case DriftVersion:
+				value, ok = config.version, true
+			case DriftPipelineHash:
+				value, ok = config.hashes[k.key]
+			case DriftPipelineSettings:
+				value, ok = config.settings[k.key]
+			case DriftPluginVersion:
+				value, ok = config.plugins[k.key]
+			}
+			if !ok {
+				value = absentValue
+			}
+			add(k, value, instances[i])
+		}
+	}
+
+	attributes := make([]DriftAttribute, 0, len(values))
+	for k, byValue := range values {
+		attribute := DriftAttribute{Kind: k.kind, Key: k.key, OddNodes: []string{}}
+		for value, nodes := range byValue {
+			sort.Strings(nodes)
+			attribute.Variants = append(attribute.Variants, DriftVariant{Value: value, Nodes: nodes})
+		}
+		sort.Slice(attribute.Variants, func(i, j int) bool {
+			a, b := attribute.Variants[i], attribute.Variants[j]
+			if len(a.Nodes) != len(b.Nodes) {
+				return len(a.Nodes) > len(b.Nodes)
+			}
+			return a.Value < b.Value
+		})
+		for _, variant := range attribute.Variants[1:] {
+			attribute.OddNodes = append(attribute.OddNodes, variant.Nodes...)
+		}
+		sort.Strings(attribute.OddNodes)
+		attributes = append(attributes, attribute)
+	}
+	sort.Slice(attributes, func(i, j int) bool {
+		if attributes[i].Kind != attributes[j].Kind {
+			return attributes[i].Kind < attributes[j].Kind
+		}
+		return attributes[i].Key < attributes[j].Key
+	})
+	return attributes
+}
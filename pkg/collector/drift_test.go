@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+
+	"go-logstash-exporter/pkg/logstashtest"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCompareConfigs(t *testing.T) {
+	a := &nodeConfig{
+		version:  "8.15.0",
+		hashes:   map[string]string{"main": "h1"},
+		settings: map[string]string{"main": "workers=4"},
+		plugins:  map[string]string{"logstash-input-beats": "6.8.0"},
+	}
+	b := &nodeConfig{
+		version:  "8.15.0",
+		hashes:   map[string]string{"main": "h2"},
+		settings: map[string]string{"main": "workers=4"},
+		plugins:  map[string]string{"logstash-input-beats": "6.8.0", "logstash-filter-grok": "4.4.3"},
+	}
+	c := &nodeConfig{
+		version:  "8.14.1",
+		hashes:   map[string]string{"main": "h1"},
+		settings: map[string]string{"main": "workers=4"},
+		plugins:  map[string]string{"logstash-input-beats": "6.8.0"},
+	}
+
+	tests := []struct {
+		name      string
+		instances []string
+		configs   []*nodeConfig
+		odd       map[string][]string // 属性类型和键到不一致的节点，省略的属性没有漂移
+	}{
+		{"配置一致", []string{"n1", "n2"}, []*nodeConfig{a, a}, nil},
+		{"版本不同", []string{"n1", "n2", "n3"}, []*nodeConfig{a, a, c}, map[string][]string{
+			"version/": {"n3"},
+		}},
+		{"pipeline hash 和插件不同", []string{"n1", "n2", "n3"}, []*nodeConfig{a, b, c}, map[string][]string{
+			"version/":                            {"n3"},
+			"pipeline_hash/main":                  {"n2"},
+			"plugin_version/logstash-filter-grok": {"n2"},
+		}},
+		// 节点数相同时按取值排序，较小的取值作为多数
+		{"两个节点各不相同", []string{"n1", "n2"}, []*nodeConfig{b, a}, map[string][]string{
+			"pipeline_hash/main":                  {"n1"},
+			"plugin_version/logstash-filter-grok": {"n2"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, attribute := range compareConfigs(tt.instances, tt.configs) {
+				want := tt.odd[attribute.Kind+"/"+attribute.Key]
+				if want == nil {
+					want = []string{}
+				}
+				if !reflect.DeepEqual(attribute.OddNodes, want) {
+					t.Errorf("%s/%s: odd_nodes = %v, want %v", attribute.Kind, attribute.Key, attribute.OddNodes, want)
+				}
+			}
+		})
+	}
+}
+
+// 漂移检测通过节点信息缓存读取 /_node，缓存有效时不会额外请求
+func TestDriftUsesNodeInfoCache(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8)
+
+	opts := DefaultOptions()
+	opts.Collectors = map[string]bool{"node_stats": true, "info": true}
+	member, err := NewWithOptions(server.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer member.Close()
+
+	cluster, err := NewClusterCollector([]*LogstashCollector{member}, ClusterOptions{Name: "ingest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(member, cluster)
+
+	for i := 0; i < 3; i++ {
+		if _, err := registry.Gather(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := server.Requests(logstashtest.PathNode); got != 1 {
+		t.Errorf("3 次抓取请求了 %d 次 /_node，want 1", got)
+	}
+	if got := server.Requests(logstashtest.PathNodePlugins); got != 1 {
+		t.Errorf("3 次抓取请求了 %d 次 /_node/plugins，want 1", got)
+	}
+}
@@ -0,0 +1,117 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// MatchConfig 定义了一组 include/exclude 正则，正则需要匹配完整的取值
+// include 为空表示全部包含，exclude 为空表示不排除
+type MatchConfig struct {
+	Include string // 只保留匹配的取值
+	Exclude string // 排除匹配的取值，优先于 include
+}
+
+// FilterConfig 定义了节点统计指标在发送前使用的 pipeline 和插件过滤规则
+type FilterConfig struct {
+	Pipelines   MatchConfig // 按 pipeline ID 过滤，作用于 pipeline 和插件指标
+	PluginTypes MatchConfig // 按插件类型（input、filter、output）过滤
+	PluginNames MatchConfig // 按插件名称过滤
+	PluginIDs   MatchConfig // 按插件 ID 过滤
+
+	// CollapsePlugins 将插件指标按 pipeline 和插件类型聚合，去掉 plugin 和 plugin_id 标签
+	CollapsePlugins bool
+}
+
+// collapsedPluginLabels 是聚合后插件指标的变量标签
+var collapsedPluginLabels = []string{"pipeline", "plugin_type"}
+
+// pluginTypes 是聚合插件指标时的插件类型顺序
+var pluginTypes = []string{"input", "filter", "output"}
+
+// matcher 是编译后的 include/exclude 正则
+type matcher struct {
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// newMatcher 编译 include/exclude 正则，field 用于错误信息
+func newMatcher(field string, c MatchConfig) (matcher, error) {
+	var m matcher
+	var err error
+	if c.Include != "" {
+		if m.include, err = regexp.Compile("^(?:" + c.Include + ")$"); err != nil {
+			return m, fmt.Errorf("%s.include 正则无效: %v", field, err)
+		}
+	}
+	if c.Exclude != "" {
+		if m.exclude, err = regexp.Compile("^(?:" + c.Exclude + ")$"); err != nil {
+			return m, fmt.Errorf("%s.exclude 正则无效: %v", field, err)
+		}
+	}
+	return m, nil
+}
+
+// match 判断取值是否应该保留
+func (m matcher) match(value string) bool {
+	if m.exclude != nil && m.exclude.MatchString(value) {
+		return false
+	}
+	return m.include == nil || m.include.MatchString(value)
+}
+
+// seriesFilter 是编译后的过滤规则，nil 表示不过滤
+type seriesFilter struct {
+	pipelines   matcher
+	pluginTypes matcher
+	pluginNames matcher
+	pluginIDs   matcher
+
+	collapsePlugins bool
+}
+
+// newSeriesFilter 编译过滤规则，正则无效时返回错误
+func newSeriesFilter(c FilterConfig) (*seriesFilter, error) {
+	f := &seriesFilter{collapsePlugins: c.CollapsePlugins}
+
+	var err error
+	if f.pipelines, err = newMatcher("pipelines", c.Pipelines); err != nil {
+		return nil, err
+	}
+	if f.pluginTypes, err = newMatcher("plugin_types", c.PluginTypes); err != nil {
+		return nil, err
+	}
+	if f.pluginNames, err = newMatcher("plugin_names", c.PluginNames); err != nil {
+		return nil, err
+	}
+	if f.pluginIDs, err = newMatcher("plugin_ids", c.PluginIDs); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// pipeline 判断 pipeline 的指标是否应该发送
+func (f *seriesFilter) pipeline(id string) bool {
+	return f == nil || f.pipelines.match(id)
+}
+
+// plugins 返回通过过滤的插件
+func (f *seriesFilter) plugins(plugins []pluginStats) []pluginStats {
+	if f == nil {
+		return plugins
+	}
+
+	kept := plugins[:0]
+	for _, p := range plugins {
+		if f.pluginTypes.match(p.Type) && f.pluginNames.match(p.Name) && f.pluginIDs.match(p.ID) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// collapse 判断插件指标是否按 pipeline 和插件类型聚合
+func (f *seriesFilter) collapse() bool {
+	return f != nil && f.collapsePlugins
+}
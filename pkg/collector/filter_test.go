@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"go-logstash-exporter/pkg/logstashtest"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMatcher(t *testing.T) {
+	tests := []struct {
+		name   string
+		config MatchConfig
+		value  string
+		want   bool
+	}{
+		{"不过滤", MatchConfig{}, "main", true},
+		{"include 匹配", MatchConfig{Include: "main|audit"}, "audit", true},
+		{"include 不匹配", MatchConfig{Include: "main"}, "audit", false},
+		{"include 需要匹配完整取值", MatchConfig{Include: "mai"}, "main", false},
+		{"exclude 匹配", MatchConfig{Exclude: "audit"}, "audit", false},
+		{"exclude 需要匹配完整取值", MatchConfig{Exclude: "aud"}, "audit", true},
+		{"exclude 优先于 include", MatchConfig{Include: ".*", Exclude: "audit"}, "audit", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := newMatcher("pipelines", tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.match(tt.value); got != tt.want {
+				t.Errorf("match(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := newSeriesFilter(FilterConfig{PluginIDs: MatchConfig{Exclude: "("}}); err == nil {
+		t.Error("无效的正则没有返回错误")
+	}
+}
+
+// 过滤规则作用于 fixture 中 main 和 audit 两个 pipeline 的插件序列
+func TestSeriesFilter(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8)
+
+	tests := []struct {
+		name   string
+		filter FilterConfig
+		labels []string // 插件序列的标签，依次为 pipeline、plugin_type 以及 plugin_id（不聚合时）
+	}{
+		{"不过滤", FilterConfig{}, []string{
+			"audit/filter/9d2b1bde9b8f3c3e7f3b3cbd1c5c2b2d6b6b0d8a1a6c8f1a9d7d8e1f2a3b4c5d", "audit/filter/date_filter", "audit/filter/mutate_cleanup", "audit/input/beats_in", "audit/output/es_out",
+			"main/filter/9d2b1bde9b8f3c3e7f3b3cbd1c5c2b2d6b6b0d8a1a6c8f1a9d7d8e1f2a3b4c5d", "main/filter/date_filter", "main/filter/mutate_cleanup", "main/input/beats_in", "main/output/es_out",
+		}},
+		{"排除 pipeline", FilterConfig{Pipelines: MatchConfig{Exclude: "audit"}}, []string{
+			"main/filter/9d2b1bde9b8f3c3e7f3b3cbd1c5c2b2d6b6b0d8a1a6c8f1a9d7d8e1f2a3b4c5d", "main/filter/date_filter", "main/filter/mutate_cleanup", "main/input/beats_in", "main/output/es_out",
+		}},
+		{"按插件类型和名称过滤", FilterConfig{
+			Pipelines:   MatchConfig{Include: "main"},
+			PluginTypes: MatchConfig{Include: "filter"},
+			PluginNames: MatchConfig{Exclude: "grok"},
+		}, []string{"main/filter/date_filter", "main/filter/mutate_cleanup"}},
+		{"按插件 ID 过滤", FilterConfig{Pipelines: MatchConfig{Include: "main"}, PluginIDs: MatchConfig{Include: "[a-z_]+"}}, []string{
+			"main/filter/date_filter", "main/filter/mutate_cleanup", "main/input/beats_in", "main/output/es_out",
+		}},
+		{"聚合插件", FilterConfig{CollapsePlugins: true}, []string{
+			"audit/filter", "audit/input", "audit/output", "main/filter", "main/input", "main/output",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Collectors = map[string]bool{"node_stats": true, "info": false}
+			opts.Filters = tt.filter
+			c, err := NewWithOptions(server.URL, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer c.Close()
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(c)
+			families, err := registry.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, family := range families {
+				if family.GetName() != "logstash_node_plugin_events_in_total" {
+					continue
+				}
+				for _, metric := range family.GetMetric() {
+					labels := map[string]string{}
+					for _, label := range metric.GetLabel() {
+						labels[label.GetName()] = label.GetValue()
+					}
+					key := labels["pipeline"] + "/" + labels["plugin_type"]
+					if id, ok := labels["plugin_id"]; ok {
+						key += "/" + id
+					}
+					got = append(got, key)
+				}
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.labels) {
+				t.Errorf("插件序列 = %v, want %v", got, tt.labels)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package collector
+
+// HealthReportResponse 定义了 Logstash /_health_report 的响应结构（Logstash 8.16 及以上）
+type HealthReportResponse struct {
+	Host        string `json:"host"`         // Host 表示 Logstash 实例的主机名
+	Version     string `json:"version"`      // Version 表示 Logstash 的版本号
+	ID          string `json:"id"`           // ID 表示节点的唯一标识符
+	EphemeralID string `json:"ephemeral_id"` // EphemeralID 表示节点的临时标识符
+	Status      string `json:"status"`       // Status 表示节点整体健康状态
+	Symptom     string `json:"symptom"`      // Symptom 表示健康状态的简要说明
+
+	Indicators struct {
+		Pipelines struct {
+			Status     string                       `json:"status"`     // Status 表示所有 pipeline 的整体健康状态
+			Indicators map[string]PipelineIndicator `json:"indicators"` // Indicators 包含了每个 pipeline 的健康指标
+		} `json:"pipelines"` // Pipelines 包含了 pipeline 健康指标
+	} `json:"indicators"` // Indicators 包含了各项健康指标
+}
+
+// PipelineIndicator 定义了单个 pipeline 的健康指标
+type PipelineIndicator struct {
+	Status  string `json:"status"`  // Status 表示 pipeline 健康状态
+	Symptom string `json:"symptom"` // Symptom 表示健康状态的简要说明
+
+	Details struct {
+		Status struct {
+			State string `json:"state"` // State 表示 pipeline 运行状态
+		} `json:"status"`
+		Flow struct {
+			WorkerUtilization struct {
+				Current    float64 `json:"current"`       // Current 表示当前 worker 利用率
+				LastMinute float64 `json:"last_1_minute"` // LastMinute 表示最近 1 分钟的 worker 利用率
+			} `json:"worker_utilization"`
+		} `json:"flow"`
+	} `json:"details"` // Details 包含了 pipeline 的运行状态和流量指标
+}
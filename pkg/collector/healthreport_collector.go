@@ -0,0 +1,129 @@
+package collector
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// healthStatuses 是健康报告中可能出现的状态，每个状态输出一条样本，当前状态取值为 1
+var healthStatuses = []string{"green", "yellow", "red", "unknown"}
+
+// HealthReportCollector 健康报告收集器
+// /_health_report 仅在 Logstash 8.16 及以上版本提供，默认关闭
+type HealthReportCollector struct {
+	client *APIClient // Logstash API 客户端
+
+	Status                    *prometheus.Desc // 节点健康状态
+	PipelineStatus            *prometheus.Desc // pipeline 健康状态
+	PipelineWorkerUtilization *prometheus.Desc // pipeline worker 利用率
+}
+
+// NewHealthReportCollector 使用默认参数创建新的健康报告收集器
+func NewHealthReportCollector(logstashEndpoint string, instance string) (Collector, error) {
+	env, err := newCollectorEnv(logstashEndpoint, instance)
+	if err != nil {
+		return nil, err
+	}
+	return newHealthReportCollector(env)
+}
+
+// newHealthReportCollector 基于共享的 API 客户端创建收集器
+func newHealthReportCollector(env *collectorEnv) (Collector, error) {
+	const subsystem = "health_report"
+
+	return &HealthReportCollector{
+		client: env.client,
+
+		Status: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "status"),
+			"Health status of the Logstash node, 1 for the current status.",
+			[]string{"status"},
+			env.constLabels,
+		),
+
+		PipelineStatus: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "pipeline_status"),
+			"Health status of the pipeline, 1 for the current status.",
+			[]string{"pipeline", "status"},
+			env.constLabels,
+		),
+
+		PipelineWorkerUtilization: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "pipeline_worker_utilization_percent"),
+			"Current worker utilization of the pipeline reported by the health report.",
+			[]string{"pipeline"},
+			env.constLabels,
+		),
+	}, nil
+}
+
+// Describe 发送健康报告指标的 Desc
+func (c *HealthReportCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.Status
+	ch <- c.PipelineStatus
+	ch <- c.PipelineWorkerUtilization
+}
+
+// Collect 入口方法，负责错误处理和调用分发；
+func (c *HealthReportCollector) Collect(ch chan<- prometheus.Metric) error {
+	if err := c.collect(ch); err != nil {
+		Errorf("Failed collecting health report metrics: %v", err)
+		return err
+	}
+	return nil
+}
+
+// collect 实际执行健康报告收集工作
+func (c *HealthReportCollector) collect(ch chan<- prometheus.Metric) error {
+	report, err := c.client.HealthReport()
+	if err != nil {
+		return err
+	}
+
+	for _, status := range healthStatuses {
+		ch <- prometheus.MustNewConstMetric(
+			c.Status,
+			prometheus.GaugeValue,
+			boolToFloat(report.Status == status),
+			status,
+		)
+	}
+
+	pipelines := report.Indicators.Pipelines.Indicators
+	ids := make([]string, 0, len(pipelines))
+	for id := range pipelines {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		pipeline := pipelines[id]
+		for _, status := range healthStatuses {
+			ch <- prometheus.MustNewConstMetric(
+				c.PipelineStatus,
+				prometheus.GaugeValue,
+				boolToFloat(pipeline.Status == status),
+				id,
+				status,
+			)
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			c.PipelineWorkerUtilization,
+			prometheus.GaugeValue,
+			pipeline.Details.Flow.WorkerUtilization.Current,
+			id,
+		)
+	}
+
+	return nil
+}
+
+// boolToFloat 将布尔值转换为 0 或 1
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
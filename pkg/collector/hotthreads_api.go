@@ -0,0 +1,21 @@
+package collector
+
+// HotThreadsResponse 定义了 Logstash /_node/hot_threads 的响应结构
+type HotThreadsResponse struct {
+	Host        string `json:"host"`         // Host 表示 Logstash 实例的主机名
+	Version     string `json:"version"`      // Version 表示 Logstash 的版本号
+	ID          string `json:"id"`           // ID 表示节点的唯一标识符
+	EphemeralID string `json:"ephemeral_id"` // EphemeralID 表示节点的临时标识符
+
+	HotThreads struct {
+		Time           string `json:"time"`            // Time 表示采样时间
+		BusiestThreads int    `json:"busiest_threads"` // BusiestThreads 表示返回的最繁忙线程数
+		Threads        []struct {
+			Name             string   `json:"name"`                // Name 表示线程名称
+			ThreadID         int64    `json:"thread_id"`           // ThreadID 表示线程 ID
+			PercentOfCPUTime float64  `json:"percent_of_cpu_time"` // PercentOfCPUTime 表示线程占用的 CPU 时间百分比
+			State            string   `json:"state"`               // State 表示线程状态
+			Traces           []string `json:"traces"`              // Traces 表示线程调用栈
+		} `json:"threads"` // Threads 包含了最繁忙的线程
+	} `json:"hot_threads"` // HotThreads 包含了热点线程信息
+}
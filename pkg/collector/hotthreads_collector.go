@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// HotThreadsCollector 热点线程收集器
+// /_node/hot_threads 需要 Logstash 对线程采样，开销较大，默认关闭
+type HotThreadsCollector struct {
+	client *APIClient // Logstash API 客户端
+
+	ThreadCPUPercent *prometheus.Desc // 线程 CPU 时间占比
+	BusiestThreads   *prometheus.Desc // 返回的最繁忙线程数
+}
+
+// NewHotThreadsCollector 使用默认参数创建新的热点线程收集器
+func NewHotThreadsCollector(logstashEndpoint string, instance string) (Collector, error) {
+	env, err := newCollectorEnv(logstashEndpoint, instance)
+	if err != nil {
+		return nil, err
+	}
+	return newHotThreadsCollector(env)
+}
+
+// newHotThreadsCollector 基于共享的 API 客户端创建收集器
+func newHotThreadsCollector(env *collectorEnv) (Collector, error) {
+	const subsystem = "hot_threads"
+
+	return &HotThreadsCollector{
+		client: env.client,
+
+		ThreadCPUPercent: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "thread_cpu_percent"),
+			"Percent of CPU time used by one of the busiest threads.",
+			[]string{"thread", "thread_id", "state"},
+			env.constLabels,
+		),
+
+		BusiestThreads: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "busiest_threads"),
+			"Number of busiest threads reported by the hot threads API.",
+			nil,
+			env.constLabels,
+		),
+	}, nil
+}
+
+// Describe 发送热点线程指标的 Desc
+func (c *HotThreadsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.ThreadCPUPercent
+	ch <- c.BusiestThreads
+}
+
+// Collect 入口方法，负责错误处理和调用分发；
+func (c *HotThreadsCollector) Collect(ch chan<- prometheus.Metric) error {
+	if err := c.collect(ch); err != nil {
+		Errorf("Failed collecting hot threads metrics: %v", err)
+		return err
+	}
+	return nil
+}
+
+// collect 实际执行热点线程收集工作
+func (c *HotThreadsCollector) collect(ch chan<- prometheus.Metric) error {
+	stats, err := c.client.HotThreads()
+	if err != nil {
+		return err
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		c.BusiestThreads,
+		prometheus.GaugeValue,
+		float64(stats.HotThreads.BusiestThreads),
+	)
+
+	for _, thread := range stats.HotThreads.Threads {
+		ch <- prometheus.MustNewConstMetric(
+			c.ThreadCPUPercent,
+			prometheus.GaugeValue,
+			thread.PercentOfCPUTime,
+			thread.Name,
+			strconv.FormatInt(thread.ThreadID, 10),
+			thread.State,
+		)
+	}
+
+	return nil
+}
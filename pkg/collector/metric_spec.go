@@ -0,0 +1,173 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metricScope 定义了指标的作用域，作用域决定了指标的变量标签以及值的来源
+type metricScope int
+
+// 支持的指标作用域
+const (
+	scopeNode     metricScope = iota // 节点级指标
+	scopeMemPool                     // JVM 内存池指标
+	scopeGC                          // 垃圾回收器指标
+	scopePipeline                    // pipeline 指标
+	scopePlugin                      // pipeline 插件指标
+)
+
+// scopeLabels 定义了每个作用域产生的变量标签（不含实例标签），顺序即标签值的顺序
+var scopeLabels = map[metricScope][]string{
+	scopeNode:     nil,
+	scopeMemPool:  {"pool"},
+	scopeGC:       {"collector"},
+	scopePipeline: {"pipeline"},
+	scopePlugin:   {"pipeline", "plugin", "plugin_id", "plugin_type"},
+}
+
+// metricSpec 声明式地描述一个指标：名称、帮助、类型、单位、标签和取值函数
+// 每个 spec 只能设置一个取值函数，取值函数的类型决定了指标的作用域，
+// 声明的标签必须与作用域产生的标签一致，这一点在创建收集器时校验
+type metricSpec struct {
+	name      string               // 指标名（不含命名空间和子系统）
+	help      string               // 帮助信息
+	valueType prometheus.ValueType // 指标类型
+	unit      string               // 导出的单位（seconds、bytes），为空表示无单位
+	source    string               // 取值函数返回值的单位，导出时换算为 unit
+	labels    []string             // 变量标签（不含实例标签）
+
+	node     func(s *NodeStatsResponse) float64     // 节点级取值
+	memPool  func(p *MemPoolStats) float64          // 内存池取值
+	gc       func(g *GCCollectorStats) float64      // 垃圾回收器取值
+	pipeline func(p *PipelineStats) (float64, bool) // pipeline 取值，false 表示跳过
+	plugin   func(p *pluginStats) (float64, bool)   // 插件取值，false 表示跳过
+}
+
+// scope 根据取值函数推导指标的作用域
+func (s metricSpec) scope() (metricScope, error) {
+	var scopes []metricScope
+	if s.node != nil {
+		scopes = append(scopes, scopeNode)
+	}
+	if s.memPool != nil {
+		scopes = append(scopes, scopeMemPool)
+	}
+	if s.gc != nil {
+		scopes = append(scopes, scopeGC)
+	}
+	if s.pipeline != nil {
+		scopes = append(scopes, scopePipeline)
+	}
+	if s.plugin != nil {
+		scopes = append(scopes, scopePlugin)
+	}
+
+	if len(scopes) != 1 {
+		return 0, fmt.Errorf("指标 %s 必须且只能设置一个取值函数，实际设置了 %d 个", s.name, len(scopes))
+	}
+	return scopes[0], nil
+}
+
+// metricNameRE 和 labelNameRE 是 Prometheus 指标名和标签名的合法格式
+var (
+	metricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRE  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// validateMetricSpecs 校验指标声明表：名称合法且不重复、单位与名称一致且可以换算、
+// 计数器以 _total 结尾、标签名合法且与取值函数的作用域数量和顺序一致
+func validateMetricSpecs(specs []metricSpec) error {
+	seen := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		if !metricNameRE.MatchString(spec.name) {
+			return fmt.Errorf("指标名 %q 不合法", spec.name)
+		}
+		if seen[spec.name] {
+			return fmt.Errorf("指标 %s 重复声明", spec.name)
+		}
+		seen[spec.name] = true
+
+		if spec.help == "" {
+			return fmt.Errorf("指标 %s 缺少帮助信息", spec.name)
+		}
+		if spec.unit != "" && !strings.HasSuffix(strings.TrimSuffix(spec.name, "_total"), "_"+spec.unit) {
+			return fmt.Errorf("指标 %s 的名称中缺少单位 %s", spec.name, spec.unit)
+		}
+		if _, err := unitDivisor(spec.source, spec.unit); err != nil {
+			return fmt.Errorf("指标 %s: %v", spec.name, err)
+		}
+		if (spec.valueType == prometheus.CounterValue) != strings.HasSuffix(spec.name, "_total") {
+			return fmt.Errorf("指标 %s 的类型与名称不一致：计数器必须且只有计数器以 _total 结尾", spec.name)
+		}
+
+		scope, err := spec.scope()
+		if err != nil {
+			return err
+		}
+
+		expected := scopeLabels[scope]
+		if len(spec.labels) != len(expected) {
+			return fmt.Errorf("指标 %s 声明了 %d 个标签 %v，但取值函数产生 %d 个标签 %v",
+				spec.name, len(spec.labels), spec.labels, len(expected), expected)
+		}
+		for i, label := range spec.labels {
+			if !labelNameRE.MatchString(label) || strings.HasPrefix(label, "__") {
+				return fmt.Errorf("指标 %s 的标签名 %q 不合法", spec.name, label)
+			}
+			if label != expected[i] {
+				return fmt.Errorf("指标 %s 的第 %d 个标签应为 %s，实际为 %s", spec.name, i+1, expected[i], label)
+			}
+		}
+	}
+
+	return nil
+}
+
+// specMetric 是根据 metricSpec 生成的指标描述
+type specMetric struct {
+	spec    metricSpec
+	scope   metricScope
+	desc    *prometheus.Desc
+	divisor float64 // 原始取值换算为导出单位时的除数
+}
+
+// convert 将取值函数的返回值换算为导出单位
+func (m specMetric) convert(value float64) float64 {
+	return value / m.divisor
+}
+
+// newSpecMetrics 校验声明表并为每个 spec 生成 Desc，实例标签作为常量标签
+func newSpecMetrics(subsystem string, specs []metricSpec, constLabels prometheus.Labels) ([]specMetric, error) {
+	if err := validateMetricSpecs(specs); err != nil {
+		return nil, err
+	}
+
+	metrics := make([]specMetric, 0, len(specs))
+	for _, spec := range specs {
+		scope, _ := spec.scope()
+		divisor, _ := unitDivisor(spec.source, spec.unit)
+
+		metrics = append(metrics, specMetric{
+			spec:    spec,
+			scope:   scope,
+			desc:    specDesc(subsystem, spec, spec.labels, constLabels),
+			divisor: divisor,
+		})
+	}
+
+	return metrics, nil
+}
+
+// specDesc 使用指定的变量标签为 spec 生成 Desc
+func specDesc(subsystem string, spec metricSpec, labels []string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName(Namespace, subsystem, spec.name),
+		spec.help,
+		labels,
+		constLabels,
+	)
+}
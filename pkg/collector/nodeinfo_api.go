@@ -59,13 +59,9 @@ type NodeInfoResponse struct {
 // endpoint 参数指定 Logstash API 的基础 URL
 // 返回节点信息响应和可能的错误
 func NodeInfo(endpoint string) (NodeInfoResponse, error) {
-	var response NodeInfoResponse
-
-	handler := &HTTPHandler{
-		Endpoint: endpoint + "/_node",
+	client, err := NewAPIClient(endpoint, DefaultOptions())
+	if err != nil {
+		return NodeInfoResponse{}, err
 	}
-
-	err := getMetrics(handler, &response)
-
-	return response, err
-}
\ No newline at end of file
+	return client.NodeInfo()
+}
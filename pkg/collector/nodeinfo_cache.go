@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"sync"
+	"time"
+)
+
+// nodeInfoCache 按端点缓存 /_node 返回的静态节点信息
+// 版本、操作系统、JVM 和 pipeline 设置只会在重启或重载时变化，
+// 因此在 TTL 内复用缓存；当 /_node/stats 中的 ephemeral_id 或 pipeline hash
+// 与缓存时不一致时提前失效
+type nodeInfoCache struct {
+	ttl time.Duration // 缓存有效期，小于等于 0 表示不缓存
+
+	mu        sync.Mutex
+	info      *NodeInfoResponse // 缓存的节点信息
+	fetchedAt time.Time         // 缓存写入时间
+	now       func() time.Time
+}
+
+// newNodeInfoCache 创建新的节点信息缓存
+func newNodeInfoCache(ttl time.Duration) *nodeInfoCache {
+	return &nodeInfoCache{
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// get 返回未过期的缓存节点信息
+func (c *nodeInfoCache) get() (NodeInfoResponse, bool) {
+	if c == nil || c.ttl <= 0 {
+		return NodeInfoResponse{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.info == nil || c.now().Sub(c.fetchedAt) >= c.ttl {
+		return NodeInfoResponse{}, false
+	}
+	return *c.info, true
+}
+
+// set 写入最新获取的节点信息
+func (c *nodeInfoCache) set(info NodeInfoResponse) {
+	if c == nil || c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.info = &info
+	c.fetchedAt = c.now()
+}
+
+// observe 将 /_node/stats 中的节点和 pipeline 标识与缓存比较，
+// 节点重启（ephemeral_id 变化）、pipeline 增减或配置 hash 变化时使缓存失效
+func (c *nodeInfoCache) observe(stats *NodeStatsResponse) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.info == nil {
+		return
+	}
+
+	if stale := nodeInfoChanged(c.info, stats); stale {
+		Debugf("节点 %s 的 ephemeral_id 或 pipeline hash 已变化，节点信息缓存失效", stats.Host)
+		c.info = nil
+	}
+}
+
+// nodeInfoChanged 判断缓存的节点信息是否与最新的节点统计不一致
+func nodeInfoChanged(info *NodeInfoResponse, stats *NodeStatsResponse) bool {
+	if stats.EphemeralID != "" && stats.EphemeralID != info.EphemeralID {
+		return true
+	}
+
+	if len(stats.Pipelines) != len(info.Pipelines) {
+		return true
+	}
+
+	for id, pipeline := range stats.Pipelines {
+		cached, ok := info.Pipelines[id]
+		if !ok {
+			return true
+		}
+		if pipeline.Hash != "" && pipeline.Hash != cached.Hash {
+			return true
+		}
+		if pipeline.EphemeralID != "" && pipeline.EphemeralID != cached.EphemeralID {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,116 @@
+package collector
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go-logstash-exporter/pkg/logstashtest"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNodeInfoCacheTTL(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cache := newNodeInfoCache(time.Minute)
+	cache.now = func() time.Time { return now }
+
+	if _, ok := cache.get(); ok {
+		t.Fatal("空缓存不应命中")
+	}
+	cache.set(NodeInfoResponse{EphemeralID: "node-1"})
+	if info, ok := cache.get(); !ok || info.EphemeralID != "node-1" {
+		t.Fatal("有效期内应命中缓存")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := cache.get(); ok {
+		t.Error("过期的缓存不应命中")
+	}
+
+	// ttl 为 0 时不缓存
+	disabled := newNodeInfoCache(0)
+	disabled.set(NodeInfoResponse{EphemeralID: "node-1"})
+	if _, ok := disabled.get(); ok {
+		t.Error("ttl 为 0 时不应缓存")
+	}
+}
+
+// 节点统计中的 ephemeral_id 变化时，下一次抓取重新请求 /_node
+func TestNodeInfoCacheInvalidation(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8)
+
+	opts := DefaultOptions()
+	opts.Collectors = map[string]bool{"info": true, "node_stats": true}
+	c, err := NewWithOptions(server.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	for i := 0; i < 3; i++ {
+		if _, err := registry.Gather(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := server.Requests(logstashtest.PathNode); got != 1 {
+		t.Fatalf("缓存有效期内请求了 %d 次 /_node，want 1", got)
+	}
+
+	// 模拟节点重启
+	fixture, _ := logstashtest.Fixture(logstashtest.Version8, logstashtest.PathNodeStats)
+	var stats map[string]interface{}
+	if err := json.Unmarshal(fixture, &stats); err != nil {
+		t.Fatal(err)
+	}
+	stats["ephemeral_id"] = "restarted"
+	body, _ := json.Marshal(stats)
+	server.SetBody(logstashtest.PathNodeStats, body)
+
+	// 节点信息和节点统计并发收集，失效最晚在下一次抓取生效
+	registry.Gather()
+	registry.Gather()
+	if got := server.Requests(logstashtest.PathNode); got < 2 {
+		t.Errorf("ephemeral_id 变化后没有重新请求 /_node")
+	}
+}
+
+// 节点重启、pipeline 增减或重载时缓存失效
+func TestNodeInfoCacheObserve(t *testing.T) {
+	const cached = `{"ephemeral_id":"node-1","pipelines":{"main":{"ephemeral_id":"p-1","hash":"h1"}}}`
+
+	tests := []struct {
+		name  string
+		stats string
+		stale bool
+	}{
+		{"没有变化", `{"ephemeral_id":"node-1","pipelines":{"main":{"ephemeral_id":"p-1","hash":"h1"}}}`, false},
+		{"旧版本没有返回标识", `{"pipelines":{"main":{}}}`, false},
+		{"节点重启", `{"ephemeral_id":"node-2","pipelines":{"main":{"ephemeral_id":"p-1","hash":"h1"}}}`, true},
+		{"新增 pipeline", `{"ephemeral_id":"node-1","pipelines":{"main":{"ephemeral_id":"p-1","hash":"h1"},"audit":{}}}`, true},
+		{"pipeline 被替换", `{"ephemeral_id":"node-1","pipelines":{"audit":{"ephemeral_id":"p-1","hash":"h1"}}}`, true},
+		{"pipeline 配置变化", `{"ephemeral_id":"node-1","pipelines":{"main":{"ephemeral_id":"p-1","hash":"h2"}}}`, true},
+		{"pipeline 重载", `{"ephemeral_id":"node-1","pipelines":{"main":{"ephemeral_id":"p-2","hash":"h1"}}}`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var info NodeInfoResponse
+			var stats NodeStatsResponse
+			if err := json.Unmarshal([]byte(cached), &info); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tt.stats), &stats); err != nil {
+				t.Fatal(err)
+			}
+
+			cache := newNodeInfoCache(time.Minute)
+			cache.set(info)
+			cache.observe(&stats)
+			if _, ok := cache.get(); ok == tt.stale {
+				t.Errorf("observe 之后命中缓存 = %v, want %v", ok, !tt.stale)
+			}
+		})
+	}
+}
@@ -8,45 +8,61 @@ import (
 
 // NodeInfoCollector 节点信息收集器
 type NodeInfoCollector struct {
-	endpoint string // Logstash API 端点
-	instance string // 实例标识
+	client *APIClient     // Logstash API 客户端
+	cache  *nodeInfoCache // 节点信息缓存，为空时每次抓取都请求 /_node
 
 	NodeInfos *prometheus.Desc // 节点信息指标
 	OsInfos   *prometheus.Desc // 操作系统信息指标
 	JvmInfos  *prometheus.Desc // JVM 信息指标
 }
 
-// NewNodeInfoCollector 创建新的节点信息收集器
+// NewNodeInfoCollector 使用默认参数创建新的节点信息收集器
 func NewNodeInfoCollector(logstashEndpoint string, instance string) (Collector, error) {
+	env, err := newCollectorEnv(logstashEndpoint, instance)
+	if err != nil {
+		return nil, err
+	}
+	return newNodeInfoCollector(env)
+}
+
+// newNodeInfoCollector 基于共享的 API 客户端创建收集器
+func newNodeInfoCollector(env *collectorEnv) (Collector, error) {
 	const subsystem = "info"
 
 	return &NodeInfoCollector{
-		endpoint: logstashEndpoint,
-		instance: instance,
+		client: env.client,
+		cache:  env.cache,
 
 		NodeInfos: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystem, "node"),
 			"A metric with a constant '1' value labeled by Logstash version.",
-			[]string{"version", "instance"},
-			nil,
+			[]string{"version"},
+			env.constLabels,
 		),
 
 		OsInfos: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystem, "os"),
 			"A metric with a constant '1' value labeled by name, arch, version and available_processors to the OS running Logstash.",
-			[]string{"name", "arch", "version", "available_processors", "instance"},
-			nil,
+			[]string{"name", "arch", "version", "available_processors"},
+			env.constLabels,
 		),
 
 		JvmInfos: prometheus.NewDesc(
 			prometheus.BuildFQName(Namespace, subsystem, "jvm"),
 			"A metric with a constant '1' value labeled by name, version and vendor of the JVM running Logstash.",
-			[]string{"name", "version", "vendor", "instance"},
-			nil,
+			[]string{"name", "version", "vendor"},
+			env.constLabels,
 		),
 	}, nil
 }
 
+// Describe 发送节点信息指标的 Desc
+func (c *NodeInfoCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.NodeInfos
+	ch <- c.OsInfos
+	ch <- c.JvmInfos
+}
+
 // Collect 入口方法，负责错误处理和调用分发；
 func (c *NodeInfoCollector) Collect(ch chan<- prometheus.Metric) error {
 	if _, err := c.collect(ch); err != nil {
@@ -58,39 +74,51 @@ func (c *NodeInfoCollector) Collect(ch chan<- prometheus.Metric) error {
 
 // collect 实际执行节点信息收集工作
 func (c *NodeInfoCollector) collect(ch chan<- prometheus.Metric) (*prometheus.Desc, error) {
-	stats, err := NodeInfo(c.endpoint)
+	stats, err := c.nodeInfo()
 	if err != nil {
 		return nil, err
 	}
 
 	ch <- prometheus.MustNewConstMetric(
 		c.NodeInfos,
-		prometheus.CounterValue,
+		prometheus.GaugeValue,
 		float64(1),
 		stats.Version,
-		c.instance,
 	)
 
 	ch <- prometheus.MustNewConstMetric(
 		c.OsInfos,
-		prometheus.CounterValue,
+		prometheus.GaugeValue,
 		float64(1),
 		stats.Os.Name,
 		stats.Os.Arch,
 		stats.Os.Version,
 		strconv.Itoa(stats.Os.AvailableProcessors),
-		c.instance,
 	)
 
 	ch <- prometheus.MustNewConstMetric(
 		c.JvmInfos,
-		prometheus.CounterValue,
+		prometheus.GaugeValue,
 		float64(1),
 		stats.Jvm.VMName,
 		stats.Jvm.VMVersion,
 		stats.Jvm.VMVendor,
-		c.instance,
 	)
 
 	return nil, nil
 }
+
+// nodeInfo 优先返回缓存中的节点信息，缓存失效时重新请求 /_node
+func (c *NodeInfoCollector) nodeInfo() (NodeInfoResponse, error) {
+	if info, ok := c.cache.get(); ok {
+		return info, nil
+	}
+
+	info, err := c.client.NodeInfo()
+	if err != nil {
+		return info, err
+	}
+
+	c.cache.set(info)
+	return info, nil
+}
@@ -0,0 +1,15 @@
+package collector
+
+// NodePluginsResponse 定义了 Logstash /_node/plugins 的响应结构
+type NodePluginsResponse struct {
+	Host        string `json:"host"`         // Host 表示 Logstash 实例的主机名
+	Version     string `json:"version"`      // Version 表示 Logstash 的版本号
+	ID          string `json:"id"`           // ID 表示节点的唯一标识符
+	EphemeralID string `json:"ephemeral_id"` // EphemeralID 表示节点的临时标识符
+
+	Total   int `json:"total"` // Total 表示已安装的插件数量
+	Plugins []struct {
+		Name    string `json:"name"`    // Name 表示插件名称
+		Version string `json:"version"` // Version 表示插件版本
+	} `json:"plugins"` // Plugins 包含了已安装的插件
+}
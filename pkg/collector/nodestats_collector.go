@@ -1,299 +1,407 @@
 package collector
 
 import (
+	"sort"
+	"sync"
+
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 // NodeStatsCollector 负责收集 Logstash 节点的统计信息
+// 所有指标由 nodeStatsMetricSpecs 声明表生成，Desc 与发送逻辑共用同一份声明
 type NodeStatsCollector struct {
-	endpoint string // Logstash API 端点
-	instance string // Logstash 实例标识
-
-	// JVM 相关指标
-	JvmThreadsCount     *prometheus.Desc // JVM 线程数
-	JvmThreadsPeakCount *prometheus.Desc // JVM 峰值线程数
-
-	// 内存相关指标
-	MemHeapUsedInBytes         *prometheus.Desc // 堆内存使用量
-	MemHeapCommittedInBytes    *prometheus.Desc // 堆内存提交量
-	MemHeapMaxInBytes          *prometheus.Desc // 堆内存最大值
-	MemNonHeapUsedInBytes      *prometheus.Desc // 非堆内存使用量
-	MemNonHeapCommittedInBytes *prometheus.Desc // 非堆内存提交量
-
-	// 内存池相关指标
-	MemPoolPeakUsedInBytes  *prometheus.Desc // 内存池峰值使用量
-	MemPoolUsedInBytes      *prometheus.Desc // 内存池当前使用量
-	MemPoolPeakMaxInBytes   *prometheus.Desc // 内存池峰值最大值
-	MemPoolMaxInBytes       *prometheus.Desc // 内存池最大值
-	MemPoolCommittedInBytes *prometheus.Desc // 内存池提交量
-
-	// GC 相关指标
-	GCCollectionTimeInMillis *prometheus.Desc // GC 收集时间
-	GCCollectionCount        *prometheus.Desc // GC 收集次数
-
-	// 进程相关指标
-	ProcessOpenFileDescriptors    *prometheus.Desc // 打开的文件描述符数量
-	ProcessMaxFileDescriptors     *prometheus.Desc // 最大文件描述符限制
-	ProcessMemTotalVirtualInBytes *prometheus.Desc // 虚拟内存总量
-	ProcessCPUTotalInMillis       *prometheus.Desc // CPU 使用时间
-
-	// Pipeline 整体指标
-	PipelineDuration       *prometheus.Desc // Pipeline 处理时间
-	PipelineEventsIn       *prometheus.Desc // Pipeline 输入事件数
-	PipelineEventsFiltered *prometheus.Desc // Pipeline 过滤事件数
-	PipelineEventsOut      *prometheus.Desc // Pipeline 输出事件数
-
-	// Pipeline 插件指标
-	PipelinePluginEventsDuration *prometheus.Desc // 插件处理时间
-	PipelinePluginEventsIn       *prometheus.Desc // 插件输入事件数
-	PipelinePluginEventsOut      *prometheus.Desc // 插件输出事件数
-	PipelinePluginMatches        *prometheus.Desc // 插件匹配次数
-	PipelinePluginFailures       *prometheus.Desc // 插件失败次数
-
-	// Pipeline 队列指标
-	PipelineQueueEvents          *prometheus.Desc // 队列中的事件数
-	PipelineQueuePageCapacity    *prometheus.Desc // 队列页容量
-	PipelineQueueMaxQueueSize    *prometheus.Desc // 队列最大大小
-	PipelineQueueMaxUnreadEvents *prometheus.Desc // 队列最大未读事件数
-
-	// 死信队列指标
-	PipelineDeadLetterQueueSizeInBytes *prometheus.Desc // 死信队列大小
-}
-
-// NewNodeStatsCollector 创建新的节点统计信息收集器
-func NewNodeStatsCollector(logstashEndpoint string, instance string) (Collector, error) {
-	const subsystem = "node"
-
-	return &NodeStatsCollector{
-		endpoint: logstashEndpoint,
-		instance: instance,
-
-		JvmThreadsCount: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "jvm_threads_count"),
-			"jvm_threads_count",
-			[]string{"instance"},
-			nil,
-		),
-
-		JvmThreadsPeakCount: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "jvm_threads_peak_count"),
-			"jvm_threads_peak_count",
-			nil,
-			nil,
-		),
+	client *APIClient     // Logstash API 客户端
+	cache  *nodeInfoCache // 同一端点的节点信息缓存，用于在重启或重载时提前失效
+	filter *seriesFilter  // pipeline 和插件过滤规则，为空时不过滤
 
-		MemHeapUsedInBytes: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "mem_heap_used_bytes"),
-			"mem_heap_used_bytes",
-			nil,
-			nil,
-		),
-
-		MemHeapCommittedInBytes: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "mem_heap_committed_bytes"),
-			"mem_heap_committed_bytes",
-			nil,
-			nil,
-		),
-
-		MemHeapMaxInBytes: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "mem_heap_max_bytes"),
-			"mem_heap_max_bytes",
-			nil,
-			nil,
-		),
-
-		MemNonHeapUsedInBytes: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "mem_nonheap_used_bytes"),
-			"mem_nonheap_used_bytes",
-			nil,
-			nil,
-		),
+	maxSeries  int              // 单个插件指标的序列上限，小于等于 0 表示不限制
+	foldedDesc *prometheus.Desc // 因超出序列上限被折叠的序列数
 
-		MemNonHeapCommittedInBytes: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "mem_nonheap_committed_bytes"),
-			"mem_nonheap_committed_bytes",
-			nil,
-			nil,
-		),
+	restarts              *restartTracker  // 跨抓取跟踪节点和 pipeline 的 ephemeral_id
+	nodeRestartsDesc      *prometheus.Desc // 观察到的节点重启次数
+	nodeStartTimeDesc     *prometheus.Desc // 节点启动时间
+	pipelineRecreatesDesc *prometheus.Desc // 观察到的 pipeline 重建次数
+	pipelineStartTimeDesc *prometheus.Desc // pipeline 启动时间
 
-		MemPoolUsedInBytes: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "mem_pool_used_bytes"),
-			"mem_pool_used_bytes",
-			[]string{"pool"},
-			nil,
-		),
+	metrics []specMetric // 由声明表生成的指标
 
-		MemPoolPeakUsedInBytes: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "mem_pool_peak_used_bytes"),
-			"mem_pool_peak_used_bytes",
-			[]string{"pool"},
-			nil,
-		),
+	mu      sync.Mutex   // 保护 summary
+	summary *nodeSummary // 最近一次成功收集的摘要，供集群聚合和配置漂移检测使用
+}
 
-		MemPoolPeakMaxInBytes: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "mem_pool_peak_max_bytes"),
-			"mem_pool_peak_max_bytes",
-			[]string{"pool"},
-			nil,
-		),
+// nodeSummary 是一次节点统计收集的摘要
+type nodeSummary struct {
+	version     string                     // Logstash 版本
+	ephemeralID string                     // 节点临时标识，重启后变化
+	pipelines   map[string]pipelineSummary // 通过过滤的 pipeline
+}
 
-		MemPoolMaxInBytes: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "mem_pool_max_bytes"),
-			"mem_pool_max_bytes",
-			[]string{"pool"},
-			nil,
-		),
+// pipelineSummary 是单个 pipeline 的摘要
+type pipelineSummary struct {
+	events      pipelineEvents
+	hash        string // 配置 hash
+	ephemeralID string // pipeline 临时标识，重载后变化
+}
 
-		MemPoolCommittedInBytes: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "mem_pool_committed_bytes"),
-			"mem_pool_committed_bytes",
-			[]string{"pool"},
-			nil,
-		),
+// pipelineEvents 是单个 pipeline 的事件计数
+type pipelineEvents struct {
+	In, Filtered, Out int
+}
 
-		GCCollectionTimeInMillis: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "gc_collection_duration_seconds_total"),
-			"gc_collection_duration_seconds_total",
-			[]string{"collector"},
-			nil,
-		),
+// since 返回相对于 prev 的增量，某项计数变小时视为从 0 重新计数
+func (e pipelineEvents) since(prev pipelineEvents) pipelineEvents {
+	delta := func(cur, prev int) int {
+		if cur < prev {
+			return cur
+		}
+		return cur - prev
+	}
+	return pipelineEvents{
+		In:       delta(e.In, prev.In),
+		Filtered: delta(e.Filtered, prev.Filtered),
+		Out:      delta(e.Out, prev.Out),
+	}
+}
 
-		GCCollectionCount: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "gc_collection_total"),
-			"gc_collection_total",
-			[]string{"collector"},
-			nil,
-		),
+// nodeStatsMetricSpecs 是节点统计指标的声明表
+var nodeStatsMetricSpecs = []metricSpec{
+	// JVM 线程指标
+	{
+		name: "jvm_threads_count", help: "Number of live JVM threads.",
+		valueType: prometheus.GaugeValue,
+		node:      func(s *NodeStatsResponse) float64 { return float64(s.Jvm.Threads.Count) },
+	},
+	{
+		name: "jvm_threads_peak_count", help: "Peak number of live JVM threads.",
+		valueType: prometheus.GaugeValue,
+		node:      func(s *NodeStatsResponse) float64 { return float64(s.Jvm.Threads.PeakCount) },
+	},
+
+	// JVM 内存指标
+	{
+		name: "mem_heap_used_bytes", help: "JVM heap memory in use.",
+		valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes,
+		node: func(s *NodeStatsResponse) float64 { return float64(s.Jvm.Mem.HeapUsedInBytes) },
+	},
+	{
+		name: "mem_heap_committed_bytes", help: "JVM heap memory committed.",
+		valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes,
+		node: func(s *NodeStatsResponse) float64 { return float64(s.Jvm.Mem.HeapCommittedInBytes) },
+	},
+	{
+		name: "mem_heap_max_bytes", help: "Maximum JVM heap memory.",
+		valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes,
+		node: func(s *NodeStatsResponse) float64 { return float64(s.Jvm.Mem.HeapMaxInBytes) },
+	},
+	{
+		name: "mem_nonheap_used_bytes", help: "JVM non-heap memory in use.",
+		valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes,
+		node: func(s *NodeStatsResponse) float64 { return float64(s.Jvm.Mem.NonHeapUsedInBytes) },
+	},
+	{
+		name: "mem_nonheap_committed_bytes", help: "JVM non-heap memory committed.",
+		valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes,
+		node: func(s *NodeStatsResponse) float64 { return float64(s.Jvm.Mem.NonHeapCommittedInBytes) },
+	},
+
+	// JVM 内存池指标
+	{
+		name: "mem_pool_peak_used_bytes", help: "Peak memory used by the JVM memory pool.",
+		valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes, labels: []string{"pool"},
+		memPool: func(p *MemPoolStats) float64 { return float64(p.PeakUsedInBytes) },
+	},
+	{
+		name: "mem_pool_used_bytes", help: "Memory used by the JVM memory pool.",
+		valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes, labels: []string{"pool"},
+		memPool: func(p *MemPoolStats) float64 { return float64(p.UsedInBytes) },
+	},
+	{
+		name: "mem_pool_peak_max_bytes", help: "Peak maximum size of the JVM memory pool.",
+		valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes, labels: []string{"pool"},
+		memPool: func(p *MemPoolStats) float64 { return float64(p.PeakMaxInBytes) },
+	},
+	{
+		name: "mem_pool_max_bytes", help: "Maximum size of the JVM memory pool.",
+		valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes, labels: []string{"pool"},
+		memPool: func(p *MemPoolStats) float64 { return float64(p.MaxInBytes) },
+	},
+	{
+		name: "mem_pool_committed_bytes", help: "Memory committed by the JVM memory pool.",
+		valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes, labels: []string{"pool"},
+		memPool: func(p *MemPoolStats) float64 { return float64(p.CommittedInBytes) },
+	},
+
+	// GC 指标
+	{
+		name: "gc_collection_duration_seconds_total", help: "Total time spent in JVM garbage collection.",
+		valueType: prometheus.CounterValue, unit: unitSeconds, source: unitMilliseconds, labels: []string{"collector"},
+		gc: func(g *GCCollectorStats) float64 { return float64(g.CollectionTimeInMillis) },
+	},
+	{
+		name: "gc_collection_total", help: "Number of JVM garbage collections.",
+		valueType: prometheus.CounterValue, labels: []string{"collector"},
+		gc: func(g *GCCollectorStats) float64 { return float64(g.CollectionCount) },
+	},
+
+	// 进程指标
+	{
+		name: "process_open_filedescriptors", help: "Number of open file descriptors.",
+		valueType: prometheus.GaugeValue,
+		node:      func(s *NodeStatsResponse) float64 { return float64(s.Process.OpenFileDescriptors) },
+	},
+	{
+		name: "process_max_filedescriptors", help: "Maximum number of open file descriptors.",
+		valueType: prometheus.GaugeValue,
+		node:      func(s *NodeStatsResponse) float64 { return float64(s.Process.MaxFileDescriptors) },
+	},
+	{
+		name: "process_mem_total_virtual_bytes", help: "Total virtual memory of the Logstash process.",
+		valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes,
+		node: func(s *NodeStatsResponse) float64 { return float64(s.Process.Mem.TotalVirtualInBytes) },
+	},
+	{
+		name: "process_cpu_total_seconds_total", help: "Total CPU time consumed by the Logstash process.",
+		valueType: prometheus.CounterValue, unit: unitSeconds, source: unitMilliseconds,
+		node: func(s *NodeStatsResponse) float64 { return float64(s.Process.CPU.TotalInMillis) },
+	},
+
+	// Pipeline 事件指标
+	{
+		name: "pipeline_duration_seconds_total", help: "Total time spent processing events in the pipeline.",
+		valueType: prometheus.CounterValue, unit: unitSeconds, source: unitMilliseconds, labels: []string{"pipeline"},
+		pipeline: func(p *PipelineStats) (float64, bool) { return float64(p.Events.DurationInMillis), true },
+	},
+	{
+		name: "pipeline_events_in_total", help: "Number of events received by the pipeline.",
+		valueType: prometheus.CounterValue, labels: []string{"pipeline"},
+		pipeline: func(p *PipelineStats) (float64, bool) { return float64(p.Events.In), true },
+	},
+	{
+		name: "pipeline_events_filtered_total", help: "Number of events filtered by the pipeline.",
+		valueType: prometheus.CounterValue, labels: []string{"pipeline"},
+		pipeline: func(p *PipelineStats) (float64, bool) { return float64(p.Events.Filtered), true },
+	},
+	{
+		name: "pipeline_events_out_total", help: "Number of events emitted by the pipeline.",
+		valueType: prometheus.CounterValue, labels: []string{"pipeline"},
+		pipeline: func(p *PipelineStats) (float64, bool) { return float64(p.Events.Out), true },
+	},
 
-		ProcessOpenFileDescriptors: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "process_open_filedescriptors"),
-			"process_open_filedescriptors",
-			nil,
-			nil,
-		),
+	// Pipeline 插件指标
+	{
+		name: "plugin_duration_seconds_total", help: "Total time spent by the filter plugin processing events.",
+		valueType: prometheus.CounterValue, unit: unitSeconds, source: unitMilliseconds, labels: pluginLabels,
+		plugin: func(p *pluginStats) (float64, bool) { return float64(p.DurationInMillis), p.Type == "filter" },
+	},
+	{
+		name: "plugin_events_in_total", help: "Number of events received by the plugin.",
+		valueType: prometheus.CounterValue, labels: pluginLabels,
+		plugin: func(p *pluginStats) (float64, bool) { return float64(p.EventsIn), true },
+	},
+	{
+		name: "plugin_events_out_total", help: "Number of events emitted by the plugin.",
+		valueType: prometheus.CounterValue, labels: pluginLabels,
+		plugin: func(p *pluginStats) (float64, bool) { return float64(p.EventsOut), true },
+	},
+	{
+		name: "plugin_matches_total", help: "Number of events matched by the filter plugin.",
+		valueType: prometheus.CounterValue, labels: pluginLabels,
+		plugin: func(p *pluginStats) (float64, bool) { return float64(p.Matches), p.Type == "filter" },
+	},
+	{
+		name: "plugin_failures_total", help: "Number of events the filter plugin failed to process.",
+		valueType: prometheus.CounterValue, labels: pluginLabels,
+		plugin: func(p *pluginStats) (float64, bool) { return float64(p.Failures), p.Type == "filter" },
+	},
+
+	// Pipeline 队列指标，仅持久化队列发送
+	{
+		name: "queue_events", help: "Number of events in the persisted queue.",
+		valueType: prometheus.GaugeValue, labels: []string{"pipeline"},
+		pipeline: func(p *PipelineStats) (float64, bool) { return float64(p.Queue.EventsCount), persistedQueue(p) },
+	},
+	{
+		name: "queue_size_bytes", help: "Current size of the persisted queue.",
+		valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes, labels: []string{"pipeline"},
+		pipeline: func(p *PipelineStats) (float64, bool) { return float64(p.Queue.QueueSizeInBytes), persistedQueue(p) },
+	},
+	{
+		name: "queue_page_capacity_bytes", help: "Page capacity of the persisted queue.",
+		valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes, labels: []string{"pipeline"},
+		pipeline: func(p *PipelineStats) (float64, bool) {
+			return float64(p.Queue.Capacity.PageCapacityInBytes), persistedQueue(p)
+		},
+	},
+	{
+		name: "queue_max_size_bytes", help: "Maximum size of the persisted queue.",
+		valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes, labels: []string{"pipeline"},
+		pipeline: func(p *PipelineStats) (float64, bool) { return float64(p.Queue.MaxQueueSizeInBytes), persistedQueue(p) },
+	},
+	{
+		name: "queue_max_unread_events", help: "Maximum number of unread events in the persisted queue.",
+		valueType: prometheus.GaugeValue, labels: []string{"pipeline"},
+		pipeline: func(p *PipelineStats) (float64, bool) {
+			return float64(p.Queue.Capacity.MaxUnreadEvents), persistedQueue(p)
+		},
+	},
+
+	// 死信队列指标，仅在启用死信队列时发送
+	{
+		name: "dead_letter_queue_size_bytes", help: "Current size of the dead letter queue.",
+		valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes, labels: []string{"pipeline"},
+		pipeline: func(p *PipelineStats) (float64, bool) {
+			if p.DeadLetterQueue == nil {
+				return 0, false
+			}
+			return float64(p.DeadLetterQueue.QueueSizeInBytes), true
+		},
+	},
+}
 
-		ProcessMaxFileDescriptors: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "process_max_filedescriptors"),
-			"process_max_filedescriptors",
-			nil,
-			nil,
-		),
+// pluginLabels 是插件指标的变量标签
+var pluginLabels = []string{"pipeline", "plugin", "plugin_id", "plugin_type"}
 
-		ProcessMemTotalVirtualInBytes: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "process_mem_total_virtual_bytes"),
-			"process_mem_total_virtual_bytes",
-			nil,
-			nil,
-		),
+// persistedQueue 判断 pipeline 是否使用持久化队列
+func persistedQueue(p *PipelineStats) bool {
+	return p.Queue.Type != "" && p.Queue.Type != "memory"
+}
 
-		ProcessCPUTotalInMillis: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "process_cpu_total_seconds_total"),
-			"process_cpu_total_seconds_total",
-			nil,
-			nil,
-		),
+// pluginStats 是归一化后的插件统计，屏蔽 inputs、filters、outputs 结构上的差异
+type pluginStats struct {
+	Type             string // 插件类型：input、filter 或 output
+	Name             string // 插件名称
+	ID               string // 插件实例 ID
+	EventsIn         int    // 输入事件数
+	EventsOut        int    // 输出事件数
+	DurationInMillis int    // 处理耗时（毫秒）
+	Matches          int    // 匹配成功的事件数
+	Failures         int    // 处理失败的事件数
+}
 
-		PipelineDuration: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "pipeline_duration_seconds_total"),
-			"pipeline_duration_seconds_total",
-			[]string{"pipeline"},
-			nil,
-		),
+// pipelinePlugins 将 pipeline 的各类插件展开为归一化的插件列表
+func pipelinePlugins(p *PipelineStats) []pluginStats {
+	plugins := make([]pluginStats, 0, len(p.Plugins.Inputs)+len(p.Plugins.Filters)+len(p.Plugins.Outputs))
+
+	for _, plugin := range p.Plugins.Inputs {
+		// 适配 Logstash 7.5.0: input 插件只有 out，输入事件数同样使用 out
+		plugins = append(plugins, pluginStats{
+			Type:      "input",
+			Name:      plugin.Name,
+			ID:        plugin.ID,
+			EventsIn:  plugin.Events.Out,
+			EventsOut: plugin.Events.Out,
+		})
+	}
 
-		PipelineEventsIn: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "pipeline_events_in_total"),
-			"pipeline_events_in_total",
-			[]string{"pipeline"},
-			nil,
-		),
+	for _, plugin := range p.Plugins.Filters {
+		plugins = append(plugins, pluginStats{
+			Type:             "filter",
+			Name:             plugin.Name,
+			ID:               plugin.ID,
+			EventsIn:         plugin.Events.In,
+			EventsOut:        plugin.Events.Out,
+			DurationInMillis: plugin.Events.DurationInMillis,
+			Matches:          plugin.Matches,
+			Failures:         plugin.Failures,
+		})
+	}
 
-		PipelineEventsFiltered: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "pipeline_events_filtered_total"),
-			"pipeline_events_filtered_total",
-			[]string{"pipeline"},
-			nil,
-		),
+	for _, plugin := range p.Plugins.Outputs {
+		plugins = append(plugins, pluginStats{
+			Type:             "output",
+			Name:             plugin.Name,
+			ID:               plugin.ID,
+			EventsIn:         plugin.Events.In,
+			EventsOut:        plugin.Events.Out,
+			DurationInMillis: plugin.Events.DurationInMillis,
+		})
+	}
 
-		PipelineEventsOut: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "pipeline_events_out_total"),
-			"pipeline_events_out_total",
-			[]string{"pipeline"},
-			nil,
-		),
+	return plugins
+}
 
-		PipelinePluginEventsDuration: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "plugin_duration_seconds_total"),
-			"plugin_duration_seconds",
-			[]string{"pipeline", "plugin", "plugin_id", "plugin_type"},
-			nil,
-		),
+// NewNodeStatsCollector 使用默认参数创建新的节点统计信息收集器
+func NewNodeStatsCollector(logstashEndpoint string, instance string) (Collector, error) {
+	env, err := newCollectorEnv(logstashEndpoint, instance)
+	if err != nil {
+		return nil, err
+	}
+	return newNodeStatsCollector(env)
+}
 
-		PipelinePluginEventsIn: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "plugin_events_in_total"),
-			"plugin_events_in",
-			[]string{"pipeline", "plugin", "plugin_id", "plugin_type"},
-			nil,
-		),
+// newNodeStatsCollector 基于共享的 API 客户端创建收集器
+// 声明表在此处校验，标签数量不一致等错误会在启动时暴露，而不是在抓取时 panic
+func newNodeStatsCollector(env *collectorEnv) (Collector, error) {
+	const subsystem = "node"
 
-		PipelinePluginEventsOut: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "plugin_events_out_total"),
-			"plugin_events_out",
-			[]string{"pipeline", "plugin", "plugin_id", "plugin_type"},
-			nil,
-		),
+	metrics, err := newSpecMetrics(subsystem, nodeStatsMetricSpecs, env.constLabels)
+	if err != nil {
+		return nil, err
+	}
 
-		PipelinePluginMatches: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "plugin_matches_total"),
-			"plugin_matches",
-			[]string{"pipeline", "plugin", "plugin_id", "plugin_type"},
-			nil,
-		),
+	// 聚合插件指标时去掉 plugin 和 plugin_id 标签
+	if env.filter.collapse() {
+		for i := range metrics {
+			if metrics[i].scope == scopePlugin {
+				metrics[i].desc = specDesc(subsystem, metrics[i].spec, collapsedPluginLabels, env.constLabels)
+			}
+		}
+	}
 
-		PipelinePluginFailures: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "plugin_failures_total"),
-			"plugin_failures",
-			[]string{"pipeline", "plugin", "plugin_id", "plugin_type"},
-			nil,
-		),
+	return &NodeStatsCollector{
+		client:  env.client,
+		cache:   env.cache,
+		filter:  env.filter,
+		metrics: metrics,
 
-		PipelineQueueEvents: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "queue_events"),
-			"queue_events",
-			[]string{"pipeline"},
-			nil,
+		maxSeries: env.maxSeries,
+		foldedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "exporter", "folded_series"),
+			"logstash_exporter: 本次抓取中因超出序列上限而折叠到 plugin_id=\"__other__\" 的序列数。",
+			[]string{"metric"},
+			env.constLabels,
 		),
 
-		PipelineQueuePageCapacity: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "queue_page_capacity_bytes"),
-			"queue_page_capacity_bytes",
-			[]string{"pipeline"},
+		restarts: newRestartTracker(),
+		nodeRestartsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "restarts_total"),
+			"Number of Logstash restarts observed by the exporter (node ephemeral_id changes).",
 			nil,
+			env.constLabels,
 		),
-
-		PipelineQueueMaxQueueSize: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "queue_max_size_bytes"),
-			"queue_max_size_bytes",
-			[]string{"pipeline"},
+		nodeStartTimeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "start_time_seconds"),
+			"Start time of the Logstash node since unix epoch in seconds.",
 			nil,
+			env.constLabels,
 		),
-
-		PipelineQueueMaxUnreadEvents: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "queue_max_unread_events"),
-			"queue_max_unread_events",
+		pipelineRecreatesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "pipeline_recreations_total"),
+			"Number of pipeline recreations observed by the exporter (pipeline ephemeral_id changes, excluding node restarts).",
 			[]string{"pipeline"},
-			nil,
+			env.constLabels,
 		),
-
-		PipelineDeadLetterQueueSizeInBytes: prometheus.NewDesc(
-			prometheus.BuildFQName(Namespace, subsystem, "dead_letter_queue_size_bytes"),
-			"dead_letter_queue_size_bytes",
+		pipelineStartTimeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, subsystem, "pipeline_start_time_seconds"),
+			"Start time of the pipeline since unix epoch in seconds.",
 			[]string{"pipeline"},
-			nil,
+			env.constLabels,
 		),
 	}, nil
 }
 
+// Describe 发送声明表中所有指标的 Desc
+func (c *NodeStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, metric := range c.metrics {
+		ch <- metric.desc
+	}
+	ch <- c.foldedDesc
+	ch <- c.nodeRestartsDesc
+	ch <- c.nodeStartTimeDesc
+	ch <- c.pipelineRecreatesDesc
+	ch <- c.pipelineStartTimeDesc
+}
+
 // Collect 入口方法，负责错误处理和调用分发；
 func (c *NodeStatsCollector) Collect(ch chan<- prometheus.Metric) error {
 	if _, err := c.collect(ch); err != nil {
@@ -305,408 +413,197 @@ func (c *NodeStatsCollector) Collect(ch chan<- prometheus.Metric) error {
 
 // collect 方法实现了实际的指标收集逻辑
 func (c *NodeStatsCollector) collect(ch chan<- prometheus.Metric) (*prometheus.Desc, error) {
-	stats, err := NodeStats(c.endpoint)
+	stats, err := c.client.NodeStats()
 	if err != nil {
+		c.setSummary(nil)
 		return nil, err
 	}
 
-	ch <- prometheus.MustNewConstMetric(
-		c.JvmThreadsCount,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Threads.Count),
-		c.instance,
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.JvmThreadsPeakCount,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Threads.PeakCount),
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemHeapUsedInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.HeapUsedInBytes),
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemHeapMaxInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.HeapMaxInBytes),
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemHeapCommittedInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.HeapCommittedInBytes),
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemNonHeapUsedInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.NonHeapUsedInBytes),
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemNonHeapCommittedInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.NonHeapCommittedInBytes),
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemPoolPeakUsedInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.Pools.Old.PeakUsedInBytes),
-		"old",
-		c.instance,
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemPoolUsedInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.Pools.Old.UsedInBytes),
-		"old",
-		c.instance,
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemPoolPeakMaxInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.Pools.Old.PeakMaxInBytes),
-		"old",
-		c.instance,
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemPoolMaxInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.Pools.Old.MaxInBytes),
-		"old",
-		c.instance,
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemPoolCommittedInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.Pools.Old.CommittedInBytes),
-		"old",
-		c.instance,
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemPoolPeakUsedInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.Pools.Old.PeakUsedInBytes),
-		"young",
-		c.instance,
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemPoolUsedInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.Pools.Young.UsedInBytes),
-		"young",
-		c.instance,
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemPoolPeakMaxInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.Pools.Old.PeakMaxInBytes),
-		"young",
-		c.instance,
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemPoolMaxInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.Pools.Young.MaxInBytes),
-		"young",
-		c.instance,
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemPoolCommittedInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.Pools.Young.CommittedInBytes),
-		"young",
-		c.instance,
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemPoolPeakUsedInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.Pools.Old.PeakUsedInBytes),
-		"survivor",
-		c.instance,
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemPoolUsedInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.Pools.Survivor.UsedInBytes),
-		"survivor",
-		c.instance,
-	)
-
-	ch <- prometheus.MustNewConstMetric(
-		c.MemPoolPeakMaxInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.Pools.Old.PeakMaxInBytes),
-		"survivor",
-		c.instance,
-	)
+	// 节点重启或 pipeline 重载后使节点信息缓存失效
+	c.cache.observe(&stats)
+	c.restarts.observe(&stats)
+
+	pools := []struct {
+		name string
+		pool *MemPoolStats
+	}{
+		{"old", &stats.Jvm.Mem.Pools.Old},
+		{"young", &stats.Jvm.Mem.Pools.Young},
+		{"survivor", &stats.Jvm.Mem.Pools.Survivor},
+	}
 
-	ch <- prometheus.MustNewConstMetric(
-		c.MemPoolMaxInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.Pools.Survivor.MaxInBytes),
-		"survivor",
-		c.instance,
-	)
+	gcs := []struct {
+		name string
+		gc   *GCCollectorStats
+	}{
+		{"old", &stats.Jvm.Gc.Collectors.Old},
+		{"young", &stats.Jvm.Gc.Collectors.Young},
+	}
 
-	ch <- prometheus.MustNewConstMetric(
-		c.MemPoolCommittedInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Mem.Pools.Survivor.CommittedInBytes),
-		"survivor",
-		c.instance,
-	)
+	// 直接使用 Pipelines，不再支持 Logstash 5.x；按 ID 排序保证输出稳定
+	// 被过滤掉的 pipeline 不发送 pipeline 和插件指标
+	pipelineIDs := make([]string, 0, len(stats.Pipelines))
+	for id := range stats.Pipelines {
+		if c.filter.pipeline(id) {
+			pipelineIDs = append(pipelineIDs, id)
+		}
+	}
+	sort.Strings(pipelineIDs)
 
-	ch <- prometheus.MustNewConstMetric(
-		c.GCCollectionTimeInMillis,
-		prometheus.CounterValue,
-		float64(stats.Jvm.Gc.Collectors.Old.CollectionTimeInMillis),
-		"old",
-		c.instance,
-	)
+	// 每个 pipeline 通过过滤的插件，所有插件指标共用
+	plugins := make(map[string][]pluginStats, len(pipelineIDs))
+	for _, id := range pipelineIDs {
+		pipeline := stats.Pipelines[id]
+		plugins[id] = c.filter.plugins(pipelinePlugins(&pipeline))
+	}
 
-	ch <- prometheus.MustNewConstMetric(
-		c.GCCollectionCount,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Gc.Collectors.Old.CollectionCount),
-		"old",
-		c.instance,
-	)
+	for _, metric := range c.metrics {
+		spec := metric.spec
+
+		switch metric.scope {
+		case scopeNode:
+			c.emit(ch, metric, spec.node(&stats))
+
+		case scopeMemPool:
+			for _, p := range pools {
+				c.emit(ch, metric, spec.memPool(p.pool), p.name)
+			}
+
+		case scopeGC:
+			for _, g := range gcs {
+				c.emit(ch, metric, spec.gc(g.gc), g.name)
+			}
+
+		case scopePipeline:
+			for _, id := range pipelineIDs {
+				pipeline := stats.Pipelines[id]
+				if value, ok := spec.pipeline(&pipeline); ok {
+					c.emit(ch, metric, value, id)
+				}
+			}
+
+		case scopePlugin:
+			if c.filter.collapse() {
+				for _, id := range pipelineIDs {
+					c.emitCollapsed(ch, metric, id, plugins[id])
+				}
+				continue
+			}
+
+			// 先取出所有序列，按每个 pipeline 的序列数计算上限内可以直接发送的序列
+			values := make([][]pluginValue, len(pipelineIDs))
+			counts := make([]int, len(pipelineIDs))
+			for i, id := range pipelineIDs {
+				for _, plugin := range plugins[id] {
+					if value, ok := spec.plugin(&plugin); ok {
+						values[i] = append(values[i], pluginValue{plugin: plugin, value: value})
+					}
+				}
+				counts[i] = len(values[i])
+			}
+
+			// 超出序列上限的插件序列折叠到 plugin_id="__other__"
+			guard := newSeriesGuard(c.maxSeries, counts)
+			for i, id := range pipelineIDs {
+				for _, v := range values[i] {
+					if guard.admit() {
+						c.emit(ch, metric, v.value, id, v.plugin.Name, v.plugin.ID, v.plugin.Type)
+					} else {
+						guard.fold(id, v.value)
+					}
+				}
+			}
+			c.emitFolded(ch, metric, guard)
+		}
+	}
 
-	ch <- prometheus.MustNewConstMetric(
-		c.GCCollectionTimeInMillis,
-		prometheus.CounterValue,
-		float64(stats.Jvm.Gc.Collectors.Young.CollectionTimeInMillis),
-		"young",
-		c.instance,
-	)
+	c.emitRestarts(ch, &stats, pipelineIDs)
 
-	ch <- prometheus.MustNewConstMetric(
-		c.GCCollectionCount,
-		prometheus.GaugeValue,
-		float64(stats.Jvm.Gc.Collectors.Young.CollectionCount),
-		"young",
-		c.instance,
-	)
+	summary := &nodeSummary{
+		version:     stats.Version,
+		ephemeralID: stats.EphemeralID,
+		pipelines:   make(map[string]pipelineSummary, len(pipelineIDs)),
+	}
+	for _, id := range pipelineIDs {
+		pipeline := stats.Pipelines[id]
+		summary.pipelines[id] = pipelineSummary{
+			events:      pipelineEvents{In: pipeline.Events.In, Filtered: pipeline.Events.Filtered, Out: pipeline.Events.Out},
+			hash:        pipeline.Hash,
+			ephemeralID: pipeline.EphemeralID,
+		}
+	}
+	c.setSummary(summary)
+	return nil, nil
+}
 
-	ch <- prometheus.MustNewConstMetric(
-		c.ProcessOpenFileDescriptors,
-		prometheus.GaugeValue,
-		float64(stats.Process.OpenFileDescriptors),
-		c.instance,
-	)
+// setSummary 记录最近一次收集的摘要，收集失败时为空
+func (c *NodeStatsCollector) setSummary(summary *nodeSummary) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.summary = summary
+}
 
-	ch <- prometheus.MustNewConstMetric(
-		c.ProcessMaxFileDescriptors,
-		prometheus.GaugeValue,
-		float64(stats.Process.MaxFileDescriptors),
-		c.instance,
-	)
+// lastSummary 返回最近一次成功收集的摘要
+func (c *NodeStatsCollector) lastSummary() *nodeSummary {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.summary
+}
 
-	ch <- prometheus.MustNewConstMetric(
-		c.ProcessMemTotalVirtualInBytes,
-		prometheus.GaugeValue,
-		float64(stats.Process.Mem.TotalVirtualInBytes),
-		c.instance,
-	)
+// emitRestarts 发送重启和重建计数以及节点、pipeline 的启动时间
+func (c *NodeStatsCollector) emitRestarts(ch chan<- prometheus.Metric, stats *NodeStatsResponse, pipelineIDs []string) {
+	restarts, start := c.restarts.node()
+	ch <- prometheus.MustNewConstMetric(c.nodeRestartsDesc, prometheus.CounterValue, float64(restarts))
+	ch <- prometheus.MustNewConstMetric(c.nodeStartTimeDesc, prometheus.GaugeValue, unixSeconds(start))
+
+	for _, id := range pipelineIDs {
+		pipeline := stats.Pipelines[id]
+		recreations, start := c.restarts.pipeline(id, &pipeline)
+		ch <- prometheus.MustNewConstMetric(c.pipelineRecreatesDesc, prometheus.CounterValue, float64(recreations), id)
+		ch <- prometheus.MustNewConstMetric(c.pipelineStartTimeDesc, prometheus.GaugeValue, unixSeconds(start), id)
+	}
+}
 
+// emit 发送单个指标样本，实例标签由 Desc 的常量标签提供
+func (c *NodeStatsCollector) emit(ch chan<- prometheus.Metric, metric specMetric, value float64, labelValues ...string) {
 	ch <- prometheus.MustNewConstMetric(
-		c.ProcessCPUTotalInMillis,
-		prometheus.CounterValue,
-		float64(stats.Process.CPU.TotalInMillis/1000),
-		c.instance,
+		metric.desc,
+		metric.spec.valueType,
+		metric.convert(value),
+		labelValues...,
 	)
+}
 
-	// 直接使用 Pipelines，不再支持 Logstash 5.x
-	pipelines := stats.Pipelines
-
-	for pipelineID, pipeline := range pipelines {
-		ch <- prometheus.MustNewConstMetric(
-			c.PipelineDuration,
-			prometheus.CounterValue,
-			float64(pipeline.Events.DurationInMillis/1000),
-			pipelineID,
-			c.instance,
-		)
-
-		ch <- prometheus.MustNewConstMetric(
-			c.PipelineEventsIn,
-			prometheus.CounterValue,
-			float64(pipeline.Events.In),
-			pipelineID,
-			c.instance,
-		)
-
-		ch <- prometheus.MustNewConstMetric(
-			c.PipelineEventsFiltered,
-			prometheus.CounterValue,
-			float64(pipeline.Events.Filtered),
-			pipelineID,
-			c.instance,
-		)
-
-		ch <- prometheus.MustNewConstMetric(
-			c.PipelineEventsOut,
-			prometheus.CounterValue,
-			float64(pipeline.Events.Out),
-			pipelineID,
-			c.instance,
-		)
-
-		// 收集 Input 插件指标
-		for _, plugin := range pipeline.Plugins.Inputs {
-			// 输入事件计数 (适配 Logstash 7.5.0: 使用 Out 而不是 In)
-			ch <- prometheus.MustNewConstMetric(
-				c.PipelinePluginEventsIn,
-				prometheus.CounterValue,
-				float64(plugin.Events.Out),
-				pipelineID,
-				plugin.Name,
-				plugin.ID,
-				"input",
-				c.instance,
-			)
-			// 输出事件计数
-			ch <- prometheus.MustNewConstMetric(
-				c.PipelinePluginEventsOut,
-				prometheus.CounterValue,
-				float64(plugin.Events.Out),
-				pipelineID,
-				plugin.Name,
-				plugin.ID,
-				"input",
-				c.instance,
-			)
-		}
-
-		// 收集 Filter 插件指标
-		for _, plugin := range pipeline.Plugins.Filters {
-			// 处理时间
-			ch <- prometheus.MustNewConstMetric(
-				c.PipelinePluginEventsDuration,
-				prometheus.CounterValue,
-				float64(plugin.Events.DurationInMillis/1000),
-				pipelineID,
-				plugin.Name,
-				plugin.ID,
-				"filter",
-				c.instance,
-			)
-			// 输入事件计数
-			ch <- prometheus.MustNewConstMetric(
-				c.PipelinePluginEventsIn,
-				prometheus.CounterValue,
-				float64(plugin.Events.In),
-				pipelineID,
-				plugin.Name,
-				plugin.ID,
-				"filter",
-				c.instance,
-			)
-			// 输出事件计数
-			ch <- prometheus.MustNewConstMetric(
-				c.PipelinePluginEventsOut,
-				prometheus.CounterValue,
-				float64(plugin.Events.Out),
-				pipelineID,
-				plugin.Name,
-				plugin.ID,
-				"filter",
-				c.instance,
-			)
-			// 匹配成功计数
-			ch <- prometheus.MustNewConstMetric(
-				c.PipelinePluginMatches,
-				prometheus.CounterValue,
-				float64(plugin.Matches),
-				pipelineID,
-				plugin.Name,
-				plugin.ID,
-				"filter",
-				c.instance,
-			)
-			// 处理失败计数
-			ch <- prometheus.MustNewConstMetric(
-				c.PipelinePluginFailures,
-				prometheus.CounterValue,
-				float64(plugin.Failures),
-				pipelineID,
-				plugin.Name,
-				plugin.ID,
-				"filter",
-				c.instance,
-			)
+// emitCollapsed 将 pipeline 中同类型插件的取值求和后发送，没有取值的插件类型不发送
+func (c *NodeStatsCollector) emitCollapsed(ch chan<- prometheus.Metric, metric specMetric, pipelineID string, plugins []pluginStats) {
+	sums := make(map[string]float64, len(pluginTypes))
+	for _, plugin := range plugins {
+		if value, ok := metric.spec.plugin(&plugin); ok {
+			sums[plugin.Type] += value
 		}
+	}
 
-		// 收集 Output 插件指标
-		for _, plugin := range pipeline.Plugins.Outputs {
-			// 输入事件计数
-			ch <- prometheus.MustNewConstMetric(
-				c.PipelinePluginEventsIn,
-				prometheus.CounterValue,
-				float64(plugin.Events.In),
-				pipelineID,
-				plugin.Name,
-				plugin.ID,
-				"output",
-				c.instance,
-			)
-			// 输出事件计数
-			ch <- prometheus.MustNewConstMetric(
-				c.PipelinePluginEventsOut,
-				prometheus.CounterValue,
-				float64(plugin.Events.Out),
-				pipelineID,
-				plugin.Name,
-				plugin.ID,
-				"output",
-				c.instance,
-			)
+	for _, pluginType := range pluginTypes {
+		if sum, ok := sums[pluginType]; ok {
+			c.emit(ch, metric, sum, pipelineID, pluginType)
 		}
+	}
+}
 
-		if pipeline.Queue.Type != "memory" {
-			ch <- prometheus.MustNewConstMetric(
-				c.PipelineQueueEvents,
-				prometheus.CounterValue,
-				float64(pipeline.Queue.EventsCount),
-				pipelineID,
-				c.instance,
-			)
-
-			ch <- prometheus.MustNewConstMetric(
-				c.PipelineQueuePageCapacity,
-				prometheus.CounterValue,
-				float64(pipeline.Queue.QueueSizeInBytes),
-				pipelineID,
-				c.instance,
-			)
-
-			ch <- prometheus.MustNewConstMetric(
-				c.PipelineQueueMaxQueueSize,
-				prometheus.CounterValue,
-				float64(pipeline.Queue.MaxQueueSizeInBytes),
-				pipelineID,
-				c.instance,
-			)
-		}
+// emitFolded 发送折叠桶以及被折叠的序列数，未设置序列上限时不发送
+func (c *NodeStatsCollector) emitFolded(ch chan<- prometheus.Metric, metric specMetric, guard *seriesGuard) {
+	if guard.limit <= 0 {
+		return
+	}
 
+	for _, pipeline := range guard.order {
+		c.emit(ch, metric, guard.buckets[pipeline], pipeline, overflowPluginID, overflowPluginID, overflowPluginID)
 	}
 
-	return nil, nil
+	ch <- prometheus.MustNewConstMetric(
+		c.foldedDesc,
+		prometheus.GaugeValue,
+		float64(guard.folded),
+		prometheus.BuildFQName(Namespace, "node", metric.spec.name),
+	)
 }
@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"math"
+	"testing"
+
+	"go-logstash-exporter/pkg/logstashtest"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// sampleValue 返回指标族中第一个包含 labels 中所有标签的序列的值，不存在时返回 NaN
+func sampleValue(families []*dto.MetricFamily, name string, labels map[string]string) float64 {
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			matched := 0
+			for _, label := range metric.GetLabel() {
+				if value, ok := labels[label.GetName()]; ok && value == label.GetValue() {
+					matched++
+				}
+			}
+			if matched != len(labels) {
+				continue
+			}
+			switch {
+			case metric.GetCounter() != nil:
+				return metric.GetCounter().GetValue()
+			case metric.GetGauge() != nil:
+				return metric.GetGauge().GetValue()
+			}
+		}
+	}
+	return math.NaN()
+}
+
+func TestValidateMetricSpecs(t *testing.T) {
+	node := func(s *NodeStatsResponse) float64 { return 0 }
+	pipeline := func(p *PipelineStats) (float64, bool) { return 0, true }
+
+	tests := []struct {
+		name    string
+		specs   []metricSpec
+		wantErr bool
+	}{
+		{"有效的声明", []metricSpec{
+			{name: "events_total", help: "h", valueType: prometheus.CounterValue, node: node},
+			{name: "size_bytes", help: "h", valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes, node: node},
+			{name: "duration_seconds_total", help: "h", valueType: prometheus.CounterValue, unit: unitSeconds, source: unitMilliseconds, labels: []string{"pipeline"}, pipeline: pipeline},
+		}, false},
+		{"非法的指标名", []metricSpec{{name: "bad-name", help: "h", valueType: prometheus.GaugeValue, node: node}}, true},
+		{"重复的指标名", []metricSpec{
+			{name: "events", help: "h", valueType: prometheus.GaugeValue, node: node},
+			{name: "events", help: "h", valueType: prometheus.GaugeValue, node: node},
+		}, true},
+		{"缺少帮助信息", []metricSpec{{name: "events", valueType: prometheus.GaugeValue, node: node}}, true},
+		{"名称中缺少单位", []metricSpec{{name: "size", help: "h", valueType: prometheus.GaugeValue, unit: unitBytes, source: unitBytes, node: node}}, true},
+		{"无法换算的单位", []metricSpec{{name: "size_bytes", help: "h", valueType: prometheus.GaugeValue, unit: unitBytes, source: unitMilliseconds, node: node}}, true},
+		{"计数器缺少 _total", []metricSpec{{name: "events", help: "h", valueType: prometheus.CounterValue, node: node}}, true},
+		{"仪表盘以 _total 结尾", []metricSpec{{name: "events_total", help: "h", valueType: prometheus.GaugeValue, node: node}}, true},
+		{"没有取值函数", []metricSpec{{name: "events", help: "h", valueType: prometheus.GaugeValue}}, true},
+		{"多个取值函数", []metricSpec{{name: "events", help: "h", valueType: prometheus.GaugeValue, labels: []string{"pipeline"}, node: node, pipeline: pipeline}}, true},
+		{"标签数量与作用域不一致", []metricSpec{{name: "events", help: "h", valueType: prometheus.GaugeValue, pipeline: pipeline}}, true},
+		{"标签名与作用域不一致", []metricSpec{{name: "events", help: "h", valueType: prometheus.GaugeValue, labels: []string{"id"}, pipeline: pipeline}}, true},
+		{"非法的标签名", []metricSpec{{name: "events", help: "h", valueType: prometheus.GaugeValue, labels: []string{"__pool"}, memPool: func(p *MemPoolStats) float64 { return 0 }}}, true},
+		{"节点统计声明表", nodeStatsMetricSpecs, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMetricSpecs(tt.specs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateMetricSpecs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// 声明表生成的指标名、标签和换算后的取值与 fixture 一致
+func TestNodeStatsCollectorMetrics(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8)
+
+	opts := DefaultOptions()
+	opts.Collectors = map[string]bool{"node_stats": true}
+	c, err := NewWithOptions(server.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   float64
+	}{
+		{"logstash_node_jvm_threads_count", nil, 61},
+		{"logstash_node_gc_collection_total", map[string]string{"collector": "young"}, 1977},
+		{"logstash_node_gc_collection_duration_seconds_total", map[string]string{"collector": "old"}, 1.853},
+		{"logstash_node_pipeline_events_in_total", map[string]string{"pipeline": "main"}, 1258744},
+		{"logstash_node_pipeline_duration_seconds_total", map[string]string{"pipeline": "main"}, 1641.188},
+		{"logstash_node_queue_page_capacity_bytes", map[string]string{"pipeline": "main"}, 67108864},
+	}
+	for _, tt := range tests {
+		got := sampleValue(families, tt.name, tt.labels)
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s%v = %v, want %v", tt.name, tt.labels, got, tt.want)
+		}
+	}
+}
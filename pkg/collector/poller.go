@@ -0,0 +1,187 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PollConfig 定义了后台轮询模式的参数
+type PollConfig struct {
+	Interval time.Duration // 轮询间隔，小于等于 0 表示不使用后台轮询
+	MaxAge   time.Duration // 快照的最大年龄，超过后不再发送快照中的指标，小于等于 0 表示不限制
+}
+
+// snapshot 是单个子收集器最近一次成功轮询得到的指标
+type snapshot struct {
+	metrics []prometheus.Metric
+	updated time.Time // 最近一次成功轮询的时间，零值表示还没有成功过
+	stale   bool      // 最近一次轮询失败，当前快照来自更早的轮询
+}
+
+// poller 按固定间隔在后台轮询端点的所有子收集器，抓取时发送缓存的快照
+// 抓取延迟因此不再取决于最慢的 Logstash 节点
+type poller struct {
+	collector *LogstashCollector
+	interval  time.Duration
+	maxAge    time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	snapshots map[string]*snapshot // 按子收集器名称保存的快照
+
+	ageDesc   *prometheus.Desc // 快照年龄
+	staleDesc *prometheus.Desc // 快照是否过期
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	quit      chan struct{} // 关闭时通知轮询循环退出
+	done      chan struct{} // 轮询循环退出后关闭
+}
+
+// newPoller 创建后台轮询器
+func newPoller(c *LogstashCollector, config PollConfig, constLabels prometheus.Labels) *poller {
+	return &poller{
+		collector: c,
+		interval:  config.Interval,
+		maxAge:    config.MaxAge,
+		now:       time.Now,
+		snapshots: map[string]*snapshot{},
+
+		ageDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "exporter", "snapshot_age_seconds"),
+			"logstash_exporter: 子收集器快照距最近一次成功轮询的秒数。",
+			[]string{"collector"},
+			constLabels,
+		),
+		staleDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "exporter", "snapshot_stale"),
+			"logstash_exporter: 最近一次轮询失败、正在使用旧快照时为 1。",
+			[]string{"collector"},
+			constLabels,
+		),
+
+		quit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// start 立即轮询一次，然后按间隔在后台轮询
+func (p *poller) start() {
+	p.startOnce.Do(func() {
+		go p.run()
+	})
+}
+
+// stop 停止后台轮询并等待正在进行的轮询结束
+func (p *poller) stop() {
+	p.stopOnce.Do(func() {
+		close(p.quit)
+	})
+
+	// 从未启动过的轮询器直接标记为已退出
+	p.startOnce.Do(func() {
+		close(p.done)
+	})
+	<-p.done
+}
+
+// run 是后台轮询循环
+func (p *poller) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.poll()
+
+		select {
+		case <-ticker.C:
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// poll 并发轮询所有子收集器，成功的结果替换快照，失败时保留旧快照并标记为过期
+func (p *poller) poll() {
+	wg := sync.WaitGroup{}
+	wg.Add(len(p.collector.collectors))
+
+	for name, collector := range p.collector.collectors {
+		go func(name string, collector Collector) {
+			defer wg.Done()
+			metrics, err := p.collector.scrape(name, collector)
+
+			p.mu.Lock()
+			defer p.mu.Unlock()
+
+			s, ok := p.snapshots[name]
+			if !ok {
+				s = &snapshot{}
+				p.snapshots[name] = s
+			}
+			if err != nil {
+				s.stale = true
+				return
+			}
+			s.metrics = metrics
+			s.updated = p.now()
+			s.stale = false
+		}(name, collector)
+	}
+
+	wg.Wait()
+}
+
+// describe 发送快照相关指标的 Desc
+func (p *poller) describe(ch chan<- *prometheus.Desc) {
+	ch <- p.ageDesc
+	ch <- p.staleDesc
+}
+
+// collect 发送快照中的指标以及快照的年龄和过期状态
+// 超过最大年龄的快照只发送年龄和过期状态，不再发送其中的指标
+func (p *poller) collect(ch chan<- prometheus.Metric) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	for name, s := range p.snapshots {
+		stale := s.stale
+		if !s.updated.IsZero() {
+			age := now.Sub(s.updated)
+			ch <- prometheus.MustNewConstMetric(p.ageDesc, prometheus.GaugeValue, age.Seconds(), name)
+
+			if p.maxAge > 0 && age > p.maxAge {
+				stale = true
+			} else {
+				for _, m := range s.metrics {
+					ch <- m
+				}
+			}
+		}
+
+		ch <- prometheus.MustNewConstMetric(p.staleDesc, prometheus.GaugeValue, boolToFloat(stale), name)
+	}
+}
+
+// fresh 判断所有子收集器是否都有成功轮询且未超过最大年龄的快照
+func (p *poller) fresh() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	for name := range p.collector.collectors {
+		s, ok := p.snapshots[name]
+		if !ok || s.stale || s.updated.IsZero() {
+			return false
+		}
+		if p.maxAge > 0 && now.Sub(s.updated) > p.maxAge {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+package collector
+
+import (
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"go-logstash-exporter/pkg/logstashtest"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// 轮询失败时继续发送旧快照并标记为过期，超过最大年龄后不再发送快照中的指标
+func TestPollerSnapshots(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8)
+
+	opts := DefaultOptions()
+	opts.Retry.MaxAttempts = 1
+	opts.Collectors = map[string]bool{"node_stats": true, "info": false}
+	opts.Poll = PollConfig{Interval: time.Hour, MaxAge: time.Minute}
+	c, err := NewWithOptions(server.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	now := time.Unix(1700000000, 0)
+	c.poller.now = func() time.Time { return now }
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	collector := map[string]string{"collector": nodeStatsCollectorName}
+	steps := []struct {
+		name    string
+		advance time.Duration
+		fault   *logstashtest.Fault // 不为空时在抓取前设置故障并轮询一次
+		metrics bool                // 是否发送快照中的指标
+		age     float64
+		stale   float64
+		fresh   bool
+	}{
+		{"首次轮询成功", 0, &logstashtest.Fault{}, true, 0, 0, true},
+		{"快照未超过最大年龄", 30 * time.Second, nil, true, 30, 0, true},
+		{"轮询失败时使用旧快照", 0, &logstashtest.Fault{StatusCode: http.StatusInternalServerError}, true, 30, 1, false},
+		{"快照超过最大年龄", time.Minute, nil, false, 90, 1, false},
+		{"轮询恢复", 0, &logstashtest.Fault{}, true, 0, 0, true},
+		{"成功的快照超过最大年龄", 2 * time.Minute, nil, false, 120, 1, false},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		if step.fault != nil {
+			server.SetFault(*step.fault)
+			c.poller.poll()
+		}
+
+		requests := server.Requests(logstashtest.PathNodeStats)
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := server.Requests(logstashtest.PathNodeStats); got != requests {
+			t.Errorf("%s: 抓取时请求了 Logstash", step.name)
+		}
+
+		if got := !math.IsNaN(sampleValue(families, "logstash_node_jvm_threads_count", nil)); got != step.metrics {
+			t.Errorf("%s: 发送快照中的指标 = %v, want %v", step.name, got, step.metrics)
+		}
+		if got := sampleValue(families, "logstash_exporter_snapshot_age_seconds", collector); got != step.age {
+			t.Errorf("%s: snapshot_age_seconds = %v, want %v", step.name, got, step.age)
+		}
+		if got := sampleValue(families, "logstash_exporter_snapshot_stale", collector); got != step.stale {
+			t.Errorf("%s: snapshot_stale = %v, want %v", step.name, got, step.stale)
+		}
+		if got := c.poller.fresh(); got != step.fresh {
+			t.Errorf("%s: fresh() = %v, want %v", step.name, got, step.fresh)
+		}
+	}
+}
+
+// 从未轮询成功的子收集器只发送过期状态
+func TestPollerNeverSucceeded(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8,
+		logstashtest.WithFault(logstashtest.Fault{StatusCode: http.StatusInternalServerError}))
+
+	opts := DefaultOptions()
+	opts.Retry.MaxAttempts = 1
+	opts.Collectors = map[string]bool{"node_stats": true, "info": false}
+	opts.Poll = PollConfig{Interval: time.Hour}
+	c, err := NewWithOptions(server.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.poller.poll()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	collector := map[string]string{"collector": nodeStatsCollectorName}
+	if got := sampleValue(families, "logstash_exporter_snapshot_stale", collector); got != 1 {
+		t.Errorf("snapshot_stale = %v, want 1", got)
+	}
+	if got := sampleValue(families, "logstash_exporter_snapshot_age_seconds", collector); !math.IsNaN(got) {
+		t.Errorf("snapshot_age_seconds = %v, want 不发送", got)
+	}
+}
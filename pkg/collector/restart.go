@@ -0,0 +1,125 @@
+package collector
+
+import (
+	"sync"
+	"time"
+)
+
+// pipelineState 是某个 pipeline 最近一次观察到的状态
+type pipelineState struct {
+	ephemeralID string    // 最近一次观察到的 ephemeral_id
+	observed    time.Time // 观察到当前 ephemeral_id 的时间，第一次抓取时为零值
+	recreations int       // 观察到的重建次数
+}
+
+// restartTracker 跨抓取跟踪节点和 pipeline 的 ephemeral_id
+// Logstash 的计数器在节点重启和 pipeline 重载时归零，ephemeral_id 的变化用来区分真正的吞吐下降和计数器重置
+type restartTracker struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	nodeID    string    // 最近一次观察到的节点 ephemeral_id
+	uptime    int64     // 最近一次观察到的 JVM 运行时间（毫秒）
+	nodeStart time.Time // 节点启动时间
+	restarts  int       // 观察到的节点重启次数
+	pipelines map[string]*pipelineState
+}
+
+// newRestartTracker 创建重启跟踪器
+func newRestartTracker() *restartTracker {
+	return &restartTracker{
+		now:       time.Now,
+		pipelines: map[string]*pipelineState{},
+	}
+}
+
+// observe 根据最新的节点统计更新重启和重建计数
+// 旧版本 Logstash 没有节点 ephemeral_id，此时以 JVM 运行时间变小作为重启的依据
+func (t *restartTracker) observe(stats *NodeStatsResponse) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	uptime := stats.Jvm.UptimeInMillis
+	first := t.nodeStart.IsZero()
+
+	restarted := !first && (stats.EphemeralID != t.nodeID || uptime < t.uptime)
+	if restarted {
+		t.restarts++
+	}
+	if first || restarted {
+		t.nodeStart = now.Add(-time.Duration(uptime) * time.Millisecond)
+	}
+	t.nodeID = stats.EphemeralID
+	t.uptime = uptime
+
+	for id, pipeline := range stats.Pipelines {
+		state, ok := t.pipelines[id]
+		switch {
+		case !ok:
+			// 第一次抓取时无法知道 pipeline 何时创建；之后新出现的 pipeline 以观察时间作为启动时间
+			state = &pipelineState{ephemeralID: pipeline.EphemeralID}
+			if !first {
+				state.observed = now
+			}
+			t.pipelines[id] = state
+		case pipeline.EphemeralID != state.ephemeralID || restarted:
+			state.ephemeralID = pipeline.EphemeralID
+			state.observed = now
+			// 节点重启已经计入 restarts，随之重建的 pipeline 不重复计数
+			if !restarted {
+				state.recreations++
+			}
+		}
+	}
+
+	// 删除的 pipeline 不再跟踪，避免配置频繁变化时状态无限增长
+	for id := range t.pipelines {
+		if _, ok := stats.Pipelines[id]; !ok {
+			delete(t.pipelines, id)
+		}
+	}
+}
+
+// node 返回观察到的节点重启次数和节点启动时间
+func (t *restartTracker) node() (restarts int, start time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.restarts, t.nodeStart
+}
+
+// pipeline 返回 pipeline 的重建次数和启动时间
+// 启动时间取节点启动时间、最近一次成功重载时间和观察到重建的时间中最晚的一个
+func (t *restartTracker) pipeline(id string, stats *PipelineStats) (recreations int, start time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	start = t.nodeStart
+	if reloaded, ok := reloadTime(stats.Reloads.LastSuccessTimestamp); ok && reloaded.After(start) {
+		start = reloaded
+	}
+
+	state, ok := t.pipelines[id]
+	if !ok {
+		return 0, start
+	}
+	if state.observed.After(start) {
+		start = state.observed
+	}
+	return state.recreations, start
+}
+
+// reloadTime 解析 reloads.last_success_timestamp，未重载过时为 null
+func reloadTime(value interface{}) (time.Time, bool) {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
@@ -0,0 +1,55 @@
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+func nodeStats(nodeID string, uptime int64, pipelines map[string]string) *NodeStatsResponse {
+	stats := &NodeStatsResponse{EphemeralID: nodeID, Pipelines: map[string]PipelineStats{}}
+	stats.Jvm.UptimeInMillis = uptime
+	for id, ephemeralID := range pipelines {
+		stats.Pipelines[id] = PipelineStats{EphemeralID: ephemeralID}
+	}
+	return stats
+}
+
+func TestRestartTracker(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tracker := newRestartTracker()
+	tracker.now = func() time.Time { return now }
+
+	recreations := func(id string) int {
+		n, _ := tracker.pipeline(id, &PipelineStats{})
+		return n
+	}
+
+	tracker.observe(nodeStats("node-1", 1000, map[string]string{"main": "p1", "beats": "b1"}))
+
+	// pipeline 重载
+	now = now.Add(time.Minute)
+	tracker.observe(nodeStats("node-1", 61000, map[string]string{"main": "p2", "beats": "b1"}))
+	if got := recreations("main"); got != 1 {
+		t.Errorf("重载后 main 的重建次数 = %d, want 1", got)
+	}
+
+	// 节点重启只计入 restarts，不重复计入 pipeline 重建
+	now = now.Add(time.Minute)
+	tracker.observe(nodeStats("node-2", 1000, map[string]string{"main": "p3", "beats": "b2"}))
+	if restarts, _ := tracker.node(); restarts != 1 {
+		t.Errorf("restarts = %d, want 1", restarts)
+	}
+	if got := recreations("main"); got != 1 {
+		t.Errorf("节点重启后 main 的重建次数 = %d, want 1", got)
+	}
+	if got := recreations("beats"); got != 0 {
+		t.Errorf("节点重启后 beats 的重建次数 = %d, want 0", got)
+	}
+
+	// 删除的 pipeline 不再跟踪
+	now = now.Add(time.Minute)
+	tracker.observe(nodeStats("node-2", 61000, map[string]string{"main": "p3"}))
+	if _, ok := tracker.pipelines["beats"]; ok {
+		t.Error("删除的 pipeline 仍然被跟踪")
+	}
+}
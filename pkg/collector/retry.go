@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy 定义了访问 Logstash API 时针对瞬时错误的重试策略
+type RetryPolicy struct {
+	MaxAttempts    int           // 最大尝试次数（包含第一次请求），小于等于 1 表示不重试
+	InitialBackoff time.Duration // 第一次重试前的基础退避时间
+	MaxBackoff     time.Duration // 单次退避时间上限
+}
+
+// DefaultRetryPolicy 返回默认的重试策略
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     2 * time.Second,
+	}
+}
+
+// backoff 计算第 attempt 次重试（从 1 开始）前的等待时间
+// 使用指数退避加全抖动（full jitter），避免多个端点同时重试
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	if p.InitialBackoff <= 0 {
+		return 0
+	}
+
+	d := p.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			d = p.MaxBackoff
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
+
+// StatusError 表示 Logstash API 返回了非 2xx 的状态码
+type StatusError struct {
+	URL        string // 请求地址
+	StatusCode int    // HTTP 状态码
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("请求 %s 返回异常状态码: %d", e.URL, e.StatusCode)
+}
+
+// isTransient 判断错误是否为可重试的瞬时错误
+// 网络错误、超时以及 429/502/503/504 状态码被视为瞬时错误
+func isTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.StatusCode {
+		case http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	if errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// flightCall 是一次正在进行或已经完成的收集
+type flightCall struct {
+	done     chan struct{}       // 收集完成后关闭
+	metrics  []prometheus.Metric // 收集得到的指标
+	err      error               // 收集 panic 时的错误
+	finished time.Time           // 完成时间
+}
+
+// flightGroup 合并同一端点上并发的收集请求：重叠的抓取共享一次 Logstash 请求，
+// 设置了最小刷新间隔时，间隔内的抓取直接复用上一次的结果
+type flightGroup struct {
+	minInterval time.Duration
+	now         func() time.Time
+
+	mu   sync.Mutex
+	call *flightCall // 正在进行的收集
+	last *flightCall // 最近一次完成的收集
+}
+
+// newFlightGroup 创建收集请求合并器
+func newFlightGroup(minInterval time.Duration) *flightGroup {
+	return &flightGroup{minInterval: minInterval, now: time.Now}
+}
+
+// do 执行 fn 或复用正在进行、或在最小刷新间隔内完成的收集结果
+// shared 为 true 表示本次请求没有执行 fn；fn panic 时执行方和等待方都得到错误，结果不会被复用
+func (g *flightGroup) do(fn func() []prometheus.Metric) (metrics []prometheus.Metric, shared bool, err error) {
+	g.mu.Lock()
+	if last := g.last; last != nil && g.minInterval > 0 && g.now().Sub(last.finished) < g.minInterval {
+		g.mu.Unlock()
+		return last.metrics, true, nil
+	}
+	if call := g.call; call != nil {
+		g.mu.Unlock()
+		<-call.done
+		return call.metrics, true, call.err
+	}
+
+	call := &flightCall{done: make(chan struct{})}
+	g.call = call
+	g.mu.Unlock()
+
+	g.run(call, fn)
+	return call.metrics, false, call.err
+}
+
+// run 执行 fn 并完成 call，fn panic 时同样清除正在进行的收集并唤醒等待方
+func (g *flightGroup) run(call *flightCall, fn func() []prometheus.Metric) {
+	defer func() {
+		if r := recover(); r != nil {
+			Errorf("收集 panic: %v\n%s", r, debug.Stack())
+			call.err = fmt.Errorf("panic: %v", r)
+		}
+
+		g.mu.Lock()
+		call.finished = g.now()
+		g.call = nil
+		if call.err == nil {
+			g.last = call
+		}
+		g.mu.Unlock()
+
+		close(call.done)
+	}()
+
+	call.metrics = fn()
+}
+
+// wait 等待正在进行的收集完成，不发起新的收集；从未收集过时执行一次 fn
+// 用于只需要最近一次结果、不应额外请求 Logstash 的调用方
+func (g *flightGroup) wait(fn func() []prometheus.Metric) {
+	g.mu.Lock()
+	call, last := g.call, g.last
+	g.mu.Unlock()
+
+	switch {
+	case call != nil:
+		<-call.done
+	case last == nil:
+		g.do(fn)
+	}
+}
@@ -0,0 +1,148 @@
+package collector
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-logstash-exporter/pkg/logstashtest"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestFlightGroupCoalesce(t *testing.T) {
+	g := newFlightGroup(0)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func() []prometheus.Metric {
+		calls.Add(1)
+		<-release
+		return nil
+	}
+
+	const callers = 5
+	var wg sync.WaitGroup
+	var shared atomic.Int32
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			if _, s, _ := g.do(fn); s {
+				shared.Add(1)
+			}
+		}()
+	}
+
+	// 等待所有调用方都进入 do 后再结束收集
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("fn 执行了 %d 次，want 1", got)
+	}
+	if got := shared.Load(); got != callers-1 {
+		t.Errorf("共享结果的调用 = %d, want %d", got, callers-1)
+	}
+}
+
+func TestFlightGroupMinInterval(t *testing.T) {
+	now := time.Unix(0, 0)
+	g := newFlightGroup(10 * time.Second)
+	g.now = func() time.Time { return now }
+
+	calls := 0
+	fn := func() []prometheus.Metric {
+		calls++
+		return nil
+	}
+
+	tests := []struct {
+		advance time.Duration
+		calls   int
+		shared  bool
+	}{
+		{0, 1, false},
+		{5 * time.Second, 1, true},  // 间隔内复用上一次的结果
+		{6 * time.Second, 2, false}, // 超过间隔后重新收集
+		{time.Second, 2, true},
+	}
+	for i, tt := range tests {
+		now = now.Add(tt.advance)
+		_, shared, _ := g.do(fn)
+		if calls != tt.calls || shared != tt.shared {
+			t.Errorf("第 %d 次: calls = %d, shared = %v, want %d, %v", i, calls, shared, tt.calls, tt.shared)
+		}
+	}
+}
+
+// fn panic 时等待方得到错误而不是永远阻塞，之后的调用重新执行 fn
+func TestFlightGroupPanic(t *testing.T) {
+	g := newFlightGroup(time.Minute)
+
+	started := make(chan struct{})
+	waiter := make(chan error, 1)
+	go func() {
+		<-started
+		_, _, err := g.do(func() []prometheus.Metric { return nil })
+		waiter <- err
+	}()
+
+	_, _, err := g.do(func() []prometheus.Metric {
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+		panic("boom")
+	})
+	if err == nil {
+		t.Error("执行方没有得到 panic 错误")
+	}
+
+	select {
+	case err := <-waiter:
+		if err == nil {
+			t.Error("等待方没有得到 panic 错误")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("收集 panic 后等待方一直阻塞")
+	}
+
+	// panic 的结果不会在最小刷新间隔内被复用
+	ran := false
+	if _, shared, err := g.do(func() []prometheus.Metric { ran = true; return nil }); !ran || shared || err != nil {
+		t.Errorf("panic 之后: ran = %v, shared = %v, err = %v", ran, shared, err)
+	}
+}
+
+// 并发的抓取合并为一次 Logstash 请求
+func TestCollectorCoalescesScrapes(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8, logstashtest.WithFault(logstashtest.Fault{
+		Paths: []string{logstashtest.PathNodeStats},
+		Delay: 50 * time.Millisecond,
+	}))
+
+	opts := DefaultOptions()
+	opts.Collectors = map[string]bool{"node_stats": true}
+	c, err := NewWithOptions(server.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			defer wg.Done()
+			registry.Gather()
+		}()
+	}
+	wg.Wait()
+
+	if got := server.Requests(logstashtest.PathNodeStats); got != 1 {
+		t.Errorf("3 次并发抓取请求了 %d 次 /_node/stats，want 1", got)
+	}
+}
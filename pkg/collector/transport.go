@@ -0,0 +1,202 @@
+package collector
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TransportMode 定义了访问 Logstash API 的传输模式
+type TransportMode string
+
+// 支持的传输模式
+const (
+	TransportLive   TransportMode = "live"   // 直接访问 Logstash
+	TransportRecord TransportMode = "record" // 访问 Logstash 并把响应录制到 fixtures 目录
+	TransportReplay TransportMode = "replay" // 不访问 Logstash，从 fixtures 目录回放响应
+)
+
+// TransportConfig 定义了录制和回放的传输配置
+type TransportConfig struct {
+	Mode        TransportMode // 传输模式，为空时等同于 live
+	FixturesDir string        // 录制和回放使用的 fixtures 目录
+}
+
+// validate 检查传输配置是否有效
+func (c TransportConfig) validate() error {
+	switch c.Mode {
+	case "", TransportLive:
+		return nil
+	case TransportRecord, TransportReplay:
+		if c.FixturesDir == "" {
+			return fmt.Errorf("传输模式 %s 需要指定 fixtures 目录", c.Mode)
+		}
+		return nil
+	default:
+		return fmt.Errorf("未知的传输模式: %s", c.Mode)
+	}
+}
+
+// newTransport 根据传输配置包装底层 RoundTripper
+func newTransport(config TransportConfig, next http.RoundTripper) http.RoundTripper {
+	switch config.Mode {
+	case TransportRecord:
+		return &recordingTransport{dir: config.FixturesDir, next: next}
+	case TransportReplay:
+		return &replayTransport{dir: config.FixturesDir}
+	default:
+		return next
+	}
+}
+
+// FixturePath 返回某个 Logstash API 地址对应的 fixture 文件路径
+// 文件按端点（host_port）组织，文件名与 logstashtest 的内置 fixture 一致：去掉各级路径开头的下划线后以下划线连接，
+// 例如 http://localhost:9600/_node/stats 对应 <dir>/localhost_9600/node_stats.json，根路径对应 root.json
+// 录制的目录可以直接复制为 logstashtest 的某个版本目录
+func FixturePath(dir string, u *url.URL) string {
+	name := "root"
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		segments := strings.Split(path, "/")
+		for i, segment := range segments {
+			segments[i] = sanitizeFixtureName(strings.TrimPrefix(segment, "_"))
+		}
+		name = strings.Join(segments, "_")
+	}
+	if u.RawQuery != "" {
+		name += "__" + sanitizeFixtureName(u.RawQuery)
+	}
+
+	return filepath.Join(dir, sanitizeFixtureName(u.Host), name+".json")
+}
+
+// sanitizeFixtureName 将名称中不适合作为文件名的字符替换为下划线
+func sanitizeFixtureName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '-' || r == '.' || r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// recordingTransport 将成功的 Logstash API 响应以解压后的 JSON 写入 fixtures 目录
+type recordingTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+func (t *recordingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	response, err := t.next.RoundTrip(request)
+	if err != nil || response.StatusCode != http.StatusOK {
+		return response, err
+	}
+
+	var reader io.Reader = response.Body
+	if strings.EqualFold(response.Header.Get("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(response.Body)
+		if err != nil {
+			response.Body.Close()
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	body, err := io.ReadAll(reader)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	path := FixturePath(t.dir, request.URL)
+	if err := writeFixture(path, body); err != nil {
+		Errorf("无法录制 fixture %s: %v", path, err)
+	} else {
+		Debugf("已录制 %s 到 %s", request.URL, path)
+	}
+
+	// 录制的是解压后的内容，返回给调用方的响应也去掉压缩标记
+	response.Header.Del("Content-Encoding")
+	response.Header.Del("Content-Length")
+	response.ContentLength = int64(len(body))
+	response.Uncompressed = true
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	return response, nil
+}
+
+// writeFixture 先在同一目录写临时文件再重命名，避免回放时读到写了一半的文件
+// 临时文件名唯一，并发录制同一路径时不会互相覆盖
+func writeFixture(path string, body []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(body); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// replayTransport 从 fixtures 目录回放响应，不访问真实的 Logstash
+// 找不到对应 fixture 时返回 404
+type replayTransport struct {
+	dir string
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+func (t *replayTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	path := FixturePath(t.dir, request.URL)
+
+	body, err := os.ReadFile(path)
+	status := http.StatusOK
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		Debugf("未找到 fixture %s", path)
+		status = http.StatusNotFound
+		body = nil
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}, nil
+}
@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// 指标和 Logstash API 取值使用的单位
+const (
+	unitSeconds      = "seconds"
+	unitMilliseconds = "milliseconds"
+	unitBytes        = "bytes"
+)
+
+// unitDivisors 是原始单位换算为导出单位的除数，使用除法而不是乘以倒数，保证换算结果是最接近的浮点数
+var unitDivisors = map[[2]string]float64{
+	{"", ""}:                        1,
+	{unitSeconds, unitSeconds}:      1,
+	{unitMilliseconds, unitSeconds}: 1000,
+	{unitBytes, unitBytes}:          1,
+}
+
+// unitDivisor 返回从 source 换算到 unit 的除数，无法换算时返回错误
+func unitDivisor(source, unit string) (float64, error) {
+	divisor, ok := unitDivisors[[2]string{source, unit}]
+	if !ok {
+		return 0, fmt.Errorf("无法将 %q 换算为 %q", source, unit)
+	}
+	return divisor, nil
+}
+
+// unixSeconds 返回带小数部分的 Unix 时间戳（秒）
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / 1e9
+}
+
+// metricUnit 根据指标名的后缀推断 OpenMetrics 单位，计数器忽略 _total 后缀
+func metricUnit(family *dto.MetricFamily) string {
+	name := family.GetName()
+	if family.GetType() == dto.MetricType_COUNTER {
+		name = strings.TrimSuffix(name, "_total")
+	}
+	for _, unit := range []string{unitSeconds, unitBytes} {
+		if strings.HasSuffix(name, "_"+unit) {
+			return unit
+		}
+	}
+	return ""
+}
+
+// unitGatherer 为收集结果补充单位元数据
+type unitGatherer struct {
+	next prometheus.Gatherer
+}
+
+// UnitGatherer 返回按指标名后缀（_seconds、_bytes）设置 OpenMetrics 单位元数据的 Gatherer
+// client_golang 的 Desc 不携带单位，单位只能在暴露前补充
+func UnitGatherer(next prometheus.Gatherer) prometheus.Gatherer {
+	return &unitGatherer{next: next}
+}
+
+// Gather 实现了 prometheus.Gatherer 接口
+func (g *unitGatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.next.Gather()
+	for _, family := range families {
+		if unit := metricUnit(family); unit != "" && family.Unit == nil {
+			family.Unit = proto.String(unit)
+		}
+	}
+	return families, err
+}
@@ -0,0 +1,63 @@
+package collector
+
+import (
+	"testing"
+
+	"go-logstash-exporter/pkg/logstashtest"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestUnitDivisor(t *testing.T) {
+	if d, err := unitDivisor(unitMilliseconds, unitSeconds); err != nil || d != 1000 {
+		t.Errorf("毫秒换算为秒: %v, %v", d, err)
+	}
+	if _, err := unitDivisor(unitBytes, unitSeconds); err == nil {
+		t.Error("字节不能换算为秒")
+	}
+}
+
+// 毫秒取值换算为带小数的秒，并按名称后缀补充单位元数据
+func TestUnitConversion(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8)
+
+	opts := DefaultOptions()
+	opts.Collectors = map[string]bool{"node_stats": true}
+	c, err := NewWithOptions(server.URL, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	families, err := UnitGatherer(registry).Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := map[string]*dto.MetricFamily{}
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+
+	gc := byName["logstash_node_gc_collection_duration_seconds_total"]
+	if gc == nil {
+		t.Fatal("缺少 GC 耗时指标")
+	}
+	if gc.GetUnit() != unitSeconds {
+		t.Errorf("GC 耗时的单位 = %q, want seconds", gc.GetUnit())
+	}
+	for _, m := range gc.GetMetric() {
+		// fixture 中 old 收集器耗时 1853 毫秒
+		if m.GetLabel()[0].GetValue() == "old" && m.GetCounter().GetValue() != 1.853 {
+			t.Errorf("old 收集器耗时 = %v, want 1.853", m.GetCounter().GetValue())
+		}
+	}
+
+	if unit := byName["logstash_node_mem_heap_used_bytes"].GetUnit(); unit != unitBytes {
+		t.Errorf("堆内存的单位 = %q, want bytes", unit)
+	}
+	if unit := byName["logstash_node_gc_collection_total"].GetUnit(); unit != "" {
+		t.Errorf("GC 次数不应有单位: %q", unit)
+	}
+}
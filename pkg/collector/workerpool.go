@@ -0,0 +1,204 @@
+package collector
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolTask 是提交给工作池的一次子收集器执行
+type poolTask struct {
+	fn       func()
+	enqueued time.Time
+	done     chan struct{}
+	err      error // 任务 panic 时的错误
+}
+
+// WorkerPool 限制所有端点同时执行的子收集器数量
+// 每个端点有自己的等待队列，空闲的 worker 在有任务的端点之间轮转取任务，
+// 单个端点积压的任务不会让其他端点一直等待
+// nil 表示不限制并发，任务在调用方的 goroutine 中直接执行
+type WorkerPool struct {
+	workers int
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queues map[string][]*poolTask // 按端点划分的等待队列
+	ring   []string               // 有等待任务的端点，按轮转顺序排列
+	queued int                    // 等待中的任务数
+	busy   int                    // 正在执行的任务数
+	closed bool
+
+	queueWait    prometheus.Histogram
+	queueLength  *prometheus.Desc
+	busyDesc     *prometheus.Desc
+	workersDesc  *prometheus.Desc
+	closeWorkers sync.WaitGroup
+}
+
+// NewWorkerPool 创建并启动包含 workers 个 worker 的工作池，workers 小于等于 0 时返回 nil（不限制并发）
+func NewWorkerPool(workers int) *WorkerPool {
+	if workers <= 0 {
+		return nil
+	}
+
+	p := &WorkerPool{
+		workers: workers,
+		queues:  map[string][]*poolTask{},
+
+		queueWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "exporter",
+			Name:      "worker_queue_wait_seconds",
+			Help:      "logstash_exporter: 子收集器在工作池中等待空闲 worker 的时间。",
+			Buckets:   prometheus.ExponentialBuckets(0.001, 4, 8),
+		}),
+		queueLength: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "exporter", "worker_queue_length"),
+			"logstash_exporter: 工作池中等待执行的子收集器数量。",
+			nil, nil,
+		),
+		busyDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "exporter", "workers_busy"),
+			"logstash_exporter: 工作池中正在执行子收集器的 worker 数量。",
+			nil, nil,
+		),
+		workersDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(Namespace, "exporter", "workers"),
+			"logstash_exporter: 工作池的 worker 总数。",
+			nil, nil,
+		),
+	}
+	p.cond = sync.NewCond(&p.mu)
+
+	p.closeWorkers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Submit 将端点 key 的任务放入工作池并等待执行完成，工作池为 nil 或已关闭时直接执行
+// 任务 panic 时返回描述 panic 的错误
+func (p *WorkerPool) Submit(key string, fn func()) error {
+	if p == nil {
+		return runTask(fn)
+	}
+
+	task := &poolTask{fn: fn, enqueued: time.Now(), done: make(chan struct{})}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return runTask(fn)
+	}
+	if len(p.queues[key]) == 0 {
+		p.ring = append(p.ring, key)
+	}
+	p.queues[key] = append(p.queues[key], task)
+	p.queued++
+	p.cond.Signal()
+	p.mu.Unlock()
+
+	<-task.done
+	return task.err
+}
+
+// next 按端点轮转取出下一个任务，调用方需持有锁
+func (p *WorkerPool) next() *poolTask {
+	key := p.ring[0]
+	queue := p.queues[key]
+	task := queue[0]
+
+	p.ring = p.ring[1:]
+	if len(queue) > 1 {
+		p.queues[key] = queue[1:]
+		p.ring = append(p.ring, key)
+	} else {
+		delete(p.queues, key)
+	}
+	p.queued--
+	return task
+}
+
+// work 是 worker 的主循环
+func (p *WorkerPool) work() {
+	defer p.closeWorkers.Done()
+
+	for {
+		p.mu.Lock()
+		for len(p.ring) == 0 && !p.closed {
+			p.cond.Wait()
+		}
+		if len(p.ring) == 0 {
+			p.mu.Unlock()
+			return
+		}
+		task := p.next()
+		p.busy++
+		p.mu.Unlock()
+
+		p.queueWait.Observe(time.Since(task.enqueued).Seconds())
+		p.run(task)
+	}
+}
+
+// run 执行任务，任务 panic 时把错误交给提交方并继续运行，保证提交方不会永远阻塞、busy 计数不会泄漏
+func (p *WorkerPool) run(task *poolTask) {
+	task.err = runTask(task.fn)
+
+	p.mu.Lock()
+	p.busy--
+	p.mu.Unlock()
+	close(task.done)
+}
+
+// runTask 执行 fn，将 panic 转换为错误并记录调用栈
+func runTask(fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			Errorf("工作池任务 panic: %v\n%s", r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	fn()
+	return nil
+}
+
+// Close 在执行完已提交的任务后停止所有 worker，之后提交的任务直接执行
+func (p *WorkerPool) Close() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	p.closed = true
+	p.cond.Broadcast()
+	p.mu.Unlock()
+
+	p.closeWorkers.Wait()
+}
+
+// Describe 实现了 prometheus.Collector 接口
+func (p *WorkerPool) Describe(ch chan<- *prometheus.Desc) {
+	p.queueWait.Describe(ch)
+	ch <- p.queueLength
+	ch <- p.busyDesc
+	ch <- p.workersDesc
+}
+
+// Collect 实现了 prometheus.Collector 接口
+func (p *WorkerPool) Collect(ch chan<- prometheus.Metric) {
+	p.mu.Lock()
+	queued, busy := p.queued, p.busy
+	p.mu.Unlock()
+
+	p.queueWait.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(p.queueLength, prometheus.GaugeValue, float64(queued))
+	ch <- prometheus.MustNewConstMetric(p.busyDesc, prometheus.GaugeValue, float64(busy))
+	ch <- prometheus.MustNewConstMetric(p.workersDesc, prometheus.GaugeValue, float64(p.workers))
+}
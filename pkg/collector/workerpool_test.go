@@ -0,0 +1,120 @@
+package collector
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-logstash-exporter/pkg/logstashtest"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestWorkerPoolPanic(t *testing.T) {
+	pool := NewWorkerPool(1)
+	defer pool.Close()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := pool.Submit("a", func() { panic("boom") }); err == nil || !strings.Contains(err.Error(), "boom") {
+			t.Errorf("Submit 返回 %v，want panic 错误", err)
+		}
+		// worker 在 panic 后继续处理后续任务
+		ran := false
+		pool.Submit("a", func() { ran = true })
+		if !ran {
+			t.Error("panic 之后的任务没有执行")
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("任务 panic 后 Submit 一直阻塞")
+	}
+
+	pool.mu.Lock()
+	busy := pool.busy
+	pool.mu.Unlock()
+	if busy != 0 {
+		t.Errorf("busy = %d, want 0", busy)
+	}
+}
+
+func TestWorkerPoolConcurrency(t *testing.T) {
+	const workers = 2
+	pool := NewWorkerPool(workers)
+	defer pool.Close()
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			pool.Submit(key, func() {
+				mu.Lock()
+				running++
+				peak = max(peak, running)
+				mu.Unlock()
+
+				time.Sleep(5 * time.Millisecond)
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+			})
+		}(string(rune('a' + i%3)))
+	}
+	wg.Wait()
+
+	if peak > workers {
+		t.Errorf("同时执行了 %d 个任务，上限 %d", peak, workers)
+	}
+}
+
+// panicCollector 是收集时 panic 的子收集器
+type panicCollector struct{}
+
+func (panicCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+func (panicCollector) Collect(ch chan<- prometheus.Metric) error {
+	panic("boom")
+}
+
+// 子收集器 panic 时按收集失败记录，无论是否使用工作池都不会让进程崩溃
+func TestScrapePanic(t *testing.T) {
+	server := logstashtest.NewTestServer(t, logstashtest.Version8)
+
+	for _, workers := range []int{0, 2} {
+		pool := NewWorkerPool(workers)
+		opts := DefaultOptions()
+		opts.Collectors = map[string]bool{"info": true}
+		opts.Pool = pool
+		c, err := NewWithOptions(server.URL, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.collectors["panic"] = panicCollector{}
+
+		c.gather()
+		if c.Succeeded() {
+			t.Errorf("workers=%d: 子收集器 panic 后 Succeeded() = true", workers)
+		}
+
+		var m dto.Metric
+		if err := c.scrapeDurations.WithLabelValues("panic", "error").(prometheus.Summary).Write(&m); err != nil {
+			t.Fatal(err)
+		}
+		if got := m.GetSummary().GetSampleCount(); got != 1 {
+			t.Errorf("workers=%d: result=\"error\" 的抓取次数 = %d, want 1", workers, got)
+		}
+
+		c.Close()
+		pool.Close()
+	}
+}
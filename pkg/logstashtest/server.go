@@ -0,0 +1,276 @@
+// logstashtest 包提供了一个模拟 Logstash API 的测试服务器
+// 服务器基于 httptest.Server，内置多个 Logstash 版本的响应 fixture，并支持故障注入：
+// 慢响应、5xx 错误、被截断的 JSON 以及认证质询
+package logstashtest
+
+import (
+	"compress/gzip"
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+//go:embed fixtures
+var fixtures embed.FS
+
+// 内置 fixture 对应的 Logstash 版本
+const (
+	Version6 = "6.8.23"
+	Version7 = "7.17.9"
+	Version8 = "8.16.1"
+)
+
+// 模拟的 Logstash API 路径
+const (
+	PathRoot         = "/"
+	PathNode         = "/_node"
+	PathNodeStats    = "/_node/stats"
+	PathNodePlugins  = "/_node/plugins"
+	PathHotThreads   = "/_node/hot_threads"
+	PathHealthReport = "/_health_report"
+)
+
+// FixtureName 返回某个 API 路径对应的 fixture 文件名，与录制模式写入的文件名一致：
+// 去掉各级路径开头的下划线后以下划线连接，例如 /_node/stats 对应 node_stats.json，根路径对应 root.json
+func FixtureName(path string) string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "root.json"
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = strings.TrimPrefix(segment, "_")
+	}
+	return strings.Join(segments, "_") + ".json"
+}
+
+// Versions 返回内置 fixture 支持的所有 Logstash 版本
+func Versions() []string {
+	entries, err := fs.ReadDir(fixtures, "fixtures")
+	if err != nil {
+		return nil
+	}
+
+	versions := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			versions = append(versions, entry.Name())
+		}
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// Fixture 返回指定版本、指定 API 路径的内置响应
+// 该版本不支持的 API（例如 8.16 之前的 /_health_report）返回 false
+func Fixture(version, path string) ([]byte, bool) {
+	body, err := fixtures.ReadFile("fixtures/" + version + "/" + FixtureName(path))
+	if err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+// Fault 描述了注入到响应中的故障
+type Fault struct {
+	Paths      []string      // 受影响的 API 路径，为空表示所有路径
+	Delay      time.Duration // 响应前的延迟
+	StatusCode int           // 非 0 时直接返回该状态码（例如 503）
+	Truncate   bool          // 只返回一半的 JSON
+}
+
+// matches 判断故障是否作用于指定路径
+func (f Fault) matches(path string) bool {
+	if len(f.Paths) == 0 {
+		return true
+	}
+	for _, p := range f.Paths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Server 是模拟 Logstash API 的测试服务器
+type Server struct {
+	*httptest.Server
+
+	version string
+
+	mu       sync.Mutex
+	bodies   map[string][]byte // 覆盖内置 fixture 的响应
+	fault    Fault             // 当前注入的故障
+	username string            // 非空时要求 Basic 认证
+	password string
+	gzip     bool           // 客户端支持时是否压缩响应
+	requests map[string]int // 按路径统计的请求次数
+}
+
+// Option 定义了测试服务器的可选配置
+type Option func(*Server)
+
+// WithFault 注入故障
+func WithFault(fault Fault) Option {
+	return func(s *Server) {
+		s.fault = fault
+	}
+}
+
+// WithBasicAuth 要求客户端使用 Basic 认证，未认证的请求返回 401 质询
+func WithBasicAuth(username, password string) Option {
+	return func(s *Server) {
+		s.username = username
+		s.password = password
+	}
+}
+
+// WithGzip 在请求携带 Accept-Encoding: gzip 时压缩响应
+func WithGzip() Option {
+	return func(s *Server) {
+		s.gzip = true
+	}
+}
+
+// WithBody 使用自定义响应覆盖某个 API 路径的内置 fixture
+func WithBody(path string, body []byte) Option {
+	return func(s *Server) {
+		s.bodies[path] = body
+	}
+}
+
+// NewServer 启动模拟指定 Logstash 版本的测试服务器
+// 调用方负责在使用完毕后调用 Close
+func NewServer(version string, opts ...Option) (*Server, error) {
+	if _, ok := Fixture(version, PathRoot); !ok {
+		return nil, fmt.Errorf("不支持的 Logstash 版本: %s（可用版本: %s）", version, strings.Join(Versions(), ", "))
+	}
+
+	s := &Server{
+		version:  version,
+		bodies:   map[string][]byte{},
+		requests: map[string]int{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s, nil
+}
+
+// NewTestServer 为测试启动模拟服务器，启动失败时终止测试，并在测试结束时自动关闭
+func NewTestServer(t testing.TB, version string, opts ...Option) *Server {
+	t.Helper()
+
+	s, err := NewServer(version, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Version 返回服务器模拟的 Logstash 版本
+func (s *Server) Version() string {
+	return s.version
+}
+
+// SetFault 替换当前注入的故障，传入零值 Fault 表示恢复正常
+func (s *Server) SetFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fault = fault
+}
+
+// SetBody 使用自定义响应覆盖某个 API 路径，传入 nil 表示恢复内置 fixture
+func (s *Server) SetBody(path string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if body == nil {
+		delete(s.bodies, path)
+		return
+	}
+	s.bodies[path] = body
+}
+
+// Requests 返回指定路径收到的请求次数
+func (s *Server) Requests(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[path]
+}
+
+// serveHTTP 处理模拟的 Logstash API 请求
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	if path != PathRoot {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	s.mu.Lock()
+	s.requests[path]++
+	fault := s.fault
+	body, overridden := s.bodies[path]
+	username, password := s.username, s.password
+	compress := s.gzip
+	s.mu.Unlock()
+
+	if username != "" {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != username || pass != password {
+			w.Header().Set("WWW-Authenticate", `Basic realm="logstash"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if fault.matches(path) {
+		if fault.Delay > 0 {
+			select {
+			case <-time.After(fault.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if fault.StatusCode != 0 {
+			http.Error(w, http.StatusText(fault.StatusCode), fault.StatusCode)
+			return
+		}
+	}
+
+	if !overridden {
+		var ok bool
+		body, ok = Fixture(s.version, path)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+	}
+
+	if fault.matches(path) && fault.Truncate {
+		body = body[:len(body)/2]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if compress && strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		gz.Write(body)
+		return
+	}
+
+	w.Write(body)
+}
@@ -0,0 +1,151 @@
+package logstashtest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// get 请求测试服务器，返回状态码和响应体
+func get(t *testing.T, s *Server, path string, header http.Header, auth ...string) (int, []byte, http.Header) {
+	t.Helper()
+
+	request, err := http.NewRequest(http.MethodGet, s.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	if len(auth) == 2 {
+		request.SetBasicAuth(auth[0], auth[1])
+	}
+
+	// 显式设置 Accept-Encoding 时 http.Transport 不会自动解压
+	response, err := http.DefaultTransport.RoundTrip(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response.StatusCode, body, response.Header
+}
+
+func TestFixtures(t *testing.T) {
+	paths := []string{PathRoot, PathNode, PathNodeStats, PathNodePlugins, PathHotThreads}
+	for _, version := range Versions() {
+		for _, path := range paths {
+			body, ok := Fixture(version, path)
+			if !ok {
+				t.Errorf("%s 缺少 %s 的 fixture", version, path)
+				continue
+			}
+			if !json.Valid(body) {
+				t.Errorf("%s 的 %s fixture 不是合法的 JSON", version, path)
+			}
+		}
+	}
+
+	if _, ok := Fixture(Version7, PathHealthReport); ok {
+		t.Error("8.16 之前的版本不应有 /_health_report")
+	}
+	if _, ok := Fixture(Version8, PathHealthReport); !ok {
+		t.Error("8.16 应有 /_health_report")
+	}
+}
+
+func TestFixtureName(t *testing.T) {
+	tests := map[string]string{
+		PathRoot:            "root.json",
+		PathNode:            "node.json",
+		PathNodeStats:       "node_stats.json",
+		PathHotThreads:      "node_hot_threads.json",
+		PathHealthReport:    "health_report.json",
+		"/_node/stats/jvm/": "node_stats_jvm.json",
+	}
+	for path, want := range tests {
+		if got := FixtureName(path); got != want {
+			t.Errorf("FixtureName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestServerFaults(t *testing.T) {
+	s := NewTestServer(t, Version8)
+
+	status, body, _ := get(t, s, PathNodeStats, nil)
+	if status != http.StatusOK || !json.Valid(body) {
+		t.Fatalf("GET %s = %d", PathNodeStats, status)
+	}
+
+	// 只作用于指定路径的 5xx
+	s.SetFault(Fault{Paths: []string{PathNodeStats}, StatusCode: http.StatusServiceUnavailable})
+	if status, _, _ := get(t, s, PathNodeStats, nil); status != http.StatusServiceUnavailable {
+		t.Errorf("注入 503 后 GET %s = %d", PathNodeStats, status)
+	}
+	if status, _, _ := get(t, s, PathNode, nil); status != http.StatusOK {
+		t.Errorf("故障不应作用于 %s: %d", PathNode, status)
+	}
+
+	// 截断的 JSON
+	s.SetFault(Fault{Truncate: true})
+	if _, body, _ := get(t, s, PathNode, nil); json.Valid(body) {
+		t.Error("截断后的响应不应是合法的 JSON")
+	}
+
+	// 慢响应
+	s.SetFault(Fault{Delay: 50 * time.Millisecond})
+	begin := time.Now()
+	get(t, s, PathNode, nil)
+	if elapsed := time.Since(begin); elapsed < 50*time.Millisecond {
+		t.Errorf("注入延迟后响应耗时 %s", elapsed)
+	}
+
+	// 自定义响应
+	s.SetFault(Fault{})
+	s.SetBody(PathNode, []byte(`{"id":"custom"}`))
+	if _, body, _ := get(t, s, PathNode, nil); string(body) != `{"id":"custom"}` {
+		t.Errorf("SetBody 之后 GET %s = %s", PathNode, body)
+	}
+	s.SetBody(PathNode, nil)
+	if _, body, _ := get(t, s, PathNode, nil); string(body) == `{"id":"custom"}` {
+		t.Error("SetBody(nil) 之后应恢复内置 fixture")
+	}
+
+	if got := s.Requests(PathNode); got != 5 {
+		t.Errorf("Requests(%s) = %d, want 5", PathNode, got)
+	}
+}
+
+func TestServerAuthAndGzip(t *testing.T) {
+	s := NewTestServer(t, Version7, WithBasicAuth("monitor", "secret"), WithGzip())
+
+	status, _, header := get(t, s, PathNode, nil)
+	if status != http.StatusUnauthorized || header.Get("WWW-Authenticate") == "" {
+		t.Errorf("未认证的请求 = %d，WWW-Authenticate = %q", status, header.Get("WWW-Authenticate"))
+	}
+	if status, _, _ := get(t, s, PathNode, nil, "monitor", "wrong"); status != http.StatusUnauthorized {
+		t.Errorf("错误密码的请求 = %d", status)
+	}
+
+	status, body, header := get(t, s, PathNode, http.Header{"Accept-Encoding": {"gzip"}}, "monitor", "secret")
+	if status != http.StatusOK || header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("认证后的压缩请求 = %d，Content-Encoding = %q", status, header.Get("Content-Encoding"))
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := io.ReadAll(gz)
+	if err != nil || !json.Valid(decoded) {
+		t.Errorf("解压后的响应无效: %v", err)
+	}
+}
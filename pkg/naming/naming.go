@@ -0,0 +1,144 @@
+// naming 包提供指标命名兼容配置，将本导出器的指标名和标签名改写为其他 Logstash 导出器使用的名称，
+// 便于迁移已有的仪表盘和告警
+package naming
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+// Native 是不做任何改写的默认命名配置
+const Native = "native"
+
+// Profile 是一套命名配置
+type Profile struct {
+	Name    string            // 配置名称
+	Metrics map[string]string // 原指标名到新指标名的映射，未列出的指标保持原名；新名与原名相同时只改写标签
+	Labels  map[string]string // 改名指标上原标签名到新标签名的映射
+
+	// InstanceLabel 是实例标签的新名称，为空表示不改写；原名取决于 instance_label 配置，由 WithInstanceLabel 补充到 Labels
+	InstanceLabel string
+}
+
+// WithInstanceLabel 返回把实例标签 label 改写为 InstanceLabel 的命名配置副本
+// 没有设置 InstanceLabel 时返回 p 本身
+func (p *Profile) WithInstanceLabel(label string) *Profile {
+	if p.InstanceLabel == "" || label == "" {
+		return p
+	}
+
+	labels := make(map[string]string, len(p.Labels)+1)
+	for from, to := range p.Labels {
+		labels[from] = to
+	}
+	labels[label] = p.InstanceLabel
+
+	copied := *p
+	copied.Labels = labels
+	return &copied
+}
+
+// Lookup 按名称查找命名配置，名称为空时返回 native
+func Lookup(name string) (*Profile, error) {
+	if name == "" {
+		name = Native
+	}
+	p, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("未知的命名配置 %q，可选值: %v", name, Names())
+	}
+	return p, nil
+}
+
+// Names 返回所有内置命名配置的名称
+func Names() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Gatherer 返回按命名配置改写指标的 Gatherer
+// dualEmit 为 true 时同时暴露原名和新名的指标，便于迁移期间新旧仪表盘并存；
+// native 配置或没有映射时直接返回 next
+func (p *Profile) Gatherer(next prometheus.Gatherer, dualEmit bool) prometheus.Gatherer {
+	if len(p.Metrics) == 0 {
+		return next
+	}
+	return &gatherer{next: next, profile: p, dualEmit: dualEmit}
+}
+
+// gatherer 在 Gather 的结果上改写指标名和标签名
+type gatherer struct {
+	next     prometheus.Gatherer
+	profile  *Profile
+	dualEmit bool
+}
+
+// Gather 实现了 prometheus.Gatherer 接口，收集只执行一次，双写时原名和新名共享同一份数据
+func (g *gatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.next.Gather()
+
+	result := make([]*dto.MetricFamily, 0, len(families))
+	for _, family := range families {
+		name, ok := g.profile.Metrics[family.GetName()]
+		if !ok {
+			result = append(result, family)
+			continue
+		}
+		// 同名的映射只改写标签，双写会产生重复的指标族
+		if g.dualEmit && name != family.GetName() {
+			result = append(result, family)
+		}
+		result = append(result, g.rename(family, name))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GetName() < result[j].GetName()
+	})
+
+	return result, err
+}
+
+// rename 复制指标族并改写指标名和标签名
+func (g *gatherer) rename(family *dto.MetricFamily, name string) *dto.MetricFamily {
+	renamed := proto.Clone(family).(*dto.MetricFamily)
+	renamed.Name = proto.String(name)
+	// 其他导出器的名称不一定带单位后缀，OpenMetrics 要求单位是名称的后缀，不满足时去掉单位
+	if !hasUnitSuffix(renamed) {
+		renamed.Unit = nil
+	}
+	if len(g.profile.Labels) == 0 {
+		return renamed
+	}
+
+	for _, metric := range renamed.Metric {
+		for _, pair := range metric.Label {
+			if label, ok := g.profile.Labels[pair.GetName()]; ok {
+				pair.Name = proto.String(label)
+			}
+		}
+		sort.Slice(metric.Label, func(i, j int) bool {
+			return metric.Label[i].GetName() < metric.Label[j].GetName()
+		})
+	}
+	return renamed
+}
+
+// hasUnitSuffix 判断指标名（计数器去掉 _total 后）是否以单位结尾，没有单位时返回 true
+func hasUnitSuffix(family *dto.MetricFamily) bool {
+	if family.Unit == nil {
+		return true
+	}
+	name := family.GetName()
+	if family.GetType() == dto.MetricType_COUNTER {
+		name = strings.TrimSuffix(name, "_total")
+	}
+	return strings.HasSuffix(name, "_"+family.GetUnit())
+}
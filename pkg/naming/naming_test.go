@@ -0,0 +1,157 @@
+package naming
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var update = flag.Bool("update", false, "更新 testdata 中的期望输出")
+
+// sourceLabels 是测试指标使用的标签，覆盖实例标签和插件指标的标签
+var sourceLabels = []string{"instance", "pipeline", "plugin", "plugin_id", "plugin_type"}
+
+// renamedFamilies 为 profile 映射的每个原指标生成一个序列，返回改写后的“指标名{标签名}”
+func renamedFamilies(t *testing.T, profile *Profile, dualEmit bool) []string {
+	t.Helper()
+
+	registry := prometheus.NewRegistry()
+	for name := range profile.Metrics {
+		gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: name}, sourceLabels)
+		gauge.WithLabelValues("localhost:9600", "main", "beats", "beats-1", "input").Set(1)
+		registry.MustRegister(gauge)
+	}
+
+	families, err := profile.Gatherer(registry, dualEmit).Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for _, family := range families {
+		var labels []string
+		for _, pair := range family.GetMetric()[0].GetLabel() {
+			labels = append(labels, pair.GetName())
+		}
+		lines = append(lines, fmt.Sprintf("%s{%s}", family.GetName(), strings.Join(labels, ",")))
+	}
+	return lines
+}
+
+// TestProfilesGolden 对照 testdata 检查每个命名配置改写后的指标名和标签名
+// 修改映射后使用 go test ./pkg/naming -update 更新期望输出
+func TestProfilesGolden(t *testing.T) {
+	for _, name := range Names() {
+		if name == Native {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			profile, err := Lookup(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			profile = profile.WithInstanceLabel("instance")
+
+			var lines []string
+			for source, target := range profile.Metrics {
+				lines = append(lines, source+" -> "+target)
+			}
+			sort.Strings(lines)
+			lines = append(lines, "")
+			lines = append(lines, renamedFamilies(t, profile, false)...)
+			got := strings.Join(lines, "\n") + "\n"
+
+			path := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(want) {
+				t.Errorf("%s 的改写结果与 %s 不一致:\n%s", name, path, got)
+			}
+		})
+	}
+}
+
+// 双写时原名和新名都暴露，同名映射只暴露一次
+func TestDualEmit(t *testing.T) {
+	for _, name := range Names() {
+		profile, _ := Lookup(name)
+		lines := renamedFamilies(t, profile.WithInstanceLabel("instance"), true)
+
+		seen := map[string]bool{}
+		for _, line := range lines {
+			family := line[:strings.Index(line, "{")]
+			if seen[family] {
+				t.Errorf("%s: 重复的指标族 %s", name, family)
+			}
+			seen[family] = true
+		}
+		for source, target := range profile.Metrics {
+			if !seen[source] || !seen[target] {
+				t.Errorf("%s: 双写缺少 %s 或 %s", name, source, target)
+			}
+		}
+	}
+}
+
+func TestNativeUnchanged(t *testing.T) {
+	profile, err := Lookup("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := prometheus.NewRegistry()
+	if profile.Gatherer(registry, true) != prometheus.Gatherer(registry) {
+		t.Error("native 配置不应包装 Gatherer")
+	}
+}
+
+// 实例标签按 instance_label 配置的名称改写
+func TestInstanceLabel(t *testing.T) {
+	profile, err := Lookup("kuskoman")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		label string
+		want  string
+	}{
+		{"instance", "hostname"},
+		{"logstash_instance", "hostname"},
+	}
+	for _, tt := range tests {
+		renamed := profile.WithInstanceLabel(tt.label)
+		if got := renamed.Labels[tt.label]; got != tt.want {
+			t.Errorf("WithInstanceLabel(%q): %s 改写为 %q, want %q", tt.label, tt.label, got, tt.want)
+		}
+	}
+	if _, ok := profile.Labels["logstash_instance"]; ok {
+		t.Error("WithInstanceLabel 修改了内置的命名配置")
+	}
+
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "logstash_node_jvm_threads_count", Help: "threads"}, []string{"logstash_instance"})
+	gauge.WithLabelValues("localhost:9600").Set(1)
+	registry.MustRegister(gauge)
+
+	families, err := profile.WithInstanceLabel("logstash_instance").Gatherer(registry, false).Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := families[0].GetMetric()[0].GetLabel()[0].GetName(); got != "hostname" {
+		t.Errorf("实例标签改写为 %s, want hostname", got)
+	}
+}
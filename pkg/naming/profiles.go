@@ -0,0 +1,83 @@
+package naming
+
+// 内置的命名配置
+// 只映射含义和单位都一致的指标：其他导出器以毫秒导出的耗时类指标没有对应关系，保持原名
+var profiles = map[string]*Profile{
+	Native: {Name: Native},
+
+	// kuskoman/logstash-exporter 的指标名
+	"kuskoman": {
+		Name: "kuskoman",
+		Metrics: map[string]string{
+			"logstash_node_jvm_threads_count":               "logstash_stats_jvm_threads_count",
+			"logstash_node_jvm_threads_peak_count":          "logstash_stats_jvm_threads_peak_count",
+			"logstash_node_mem_heap_used_bytes":             "logstash_stats_jvm_mem_heap_used_bytes",
+			"logstash_node_mem_heap_committed_bytes":        "logstash_stats_jvm_mem_heap_committed_bytes",
+			"logstash_node_mem_heap_max_bytes":              "logstash_stats_jvm_mem_heap_max_bytes",
+			"logstash_node_mem_nonheap_used_bytes":          "logstash_stats_jvm_mem_non_heap_used_bytes",
+			"logstash_node_mem_nonheap_committed_bytes":     "logstash_stats_jvm_mem_non_heap_committed_bytes",
+			"logstash_node_mem_pool_peak_used_bytes":        "logstash_stats_jvm_mem_pool_peak_used_bytes",
+			"logstash_node_mem_pool_used_bytes":             "logstash_stats_jvm_mem_pool_used_bytes",
+			"logstash_node_mem_pool_peak_max_bytes":         "logstash_stats_jvm_mem_pool_peak_max_bytes",
+			"logstash_node_mem_pool_max_bytes":              "logstash_stats_jvm_mem_pool_max_bytes",
+			"logstash_node_mem_pool_committed_bytes":        "logstash_stats_jvm_mem_pool_committed_bytes",
+			"logstash_node_process_open_filedescriptors":    "logstash_stats_process_open_file_descriptors",
+			"logstash_node_process_max_filedescriptors":     "logstash_stats_process_max_file_descriptors",
+			"logstash_node_process_mem_total_virtual_bytes": "logstash_stats_process_mem_total_virtual",
+			"logstash_node_pipeline_events_in_total":        "logstash_stats_pipeline_events_in",
+			"logstash_node_pipeline_events_filtered_total":  "logstash_stats_pipeline_events_filtered",
+			"logstash_node_pipeline_events_out_total":       "logstash_stats_pipeline_events_out",
+			"logstash_node_plugin_events_in_total":          "logstash_stats_pipeline_plugin_events_in",
+			"logstash_node_plugin_events_out_total":         "logstash_stats_pipeline_plugin_events_out",
+			"logstash_node_queue_events":                    "logstash_stats_pipeline_queue_events_count",
+			"logstash_node_queue_size_bytes":                "logstash_stats_pipeline_queue_events_queue_size",
+			"logstash_node_queue_max_size_bytes":            "logstash_stats_pipeline_queue_max_size_in_bytes",
+			// info 指标与 kuskoman 同名，列出后同样改写标签
+			"logstash_info_node": "logstash_info_node",
+			"logstash_info_os":   "logstash_info_os",
+			"logstash_info_jvm":  "logstash_info_jvm",
+		},
+		// kuskoman 以 hostname 标识实例，插件标签与本导出器相同，显式列出便于对照
+		InstanceLabel: "hostname",
+		Labels: map[string]string{
+			"pipeline":    "pipeline",
+			"plugin":      "plugin",
+			"plugin_id":   "plugin_id",
+			"plugin_type": "plugin_type",
+		},
+	},
+
+	// Metricbeat logstash 模块 node_stats 的字段名，点号替换为下划线
+	"metricbeat": {
+		Name: "metricbeat",
+		Metrics: map[string]string{
+			"logstash_node_jvm_threads_count":               "logstash_node_stats_jvm_threads_count",
+			"logstash_node_jvm_threads_peak_count":          "logstash_node_stats_jvm_threads_peak_count",
+			"logstash_node_mem_heap_used_bytes":             "logstash_node_stats_jvm_mem_heap_used_in_bytes",
+			"logstash_node_mem_heap_committed_bytes":        "logstash_node_stats_jvm_mem_heap_committed_in_bytes",
+			"logstash_node_mem_heap_max_bytes":              "logstash_node_stats_jvm_mem_heap_max_in_bytes",
+			"logstash_node_mem_nonheap_used_bytes":          "logstash_node_stats_jvm_mem_non_heap_used_in_bytes",
+			"logstash_node_mem_nonheap_committed_bytes":     "logstash_node_stats_jvm_mem_non_heap_committed_in_bytes",
+			"logstash_node_gc_collection_total":             "logstash_node_stats_jvm_gc_collectors_collection_count",
+			"logstash_node_process_open_filedescriptors":    "logstash_node_stats_process_open_file_descriptors",
+			"logstash_node_process_max_filedescriptors":     "logstash_node_stats_process_max_file_descriptors",
+			"logstash_node_process_mem_total_virtual_bytes": "logstash_node_stats_process_mem_total_virtual_in_bytes",
+			"logstash_node_pipeline_events_in_total":        "logstash_node_stats_pipelines_events_in",
+			"logstash_node_pipeline_events_filtered_total":  "logstash_node_stats_pipelines_events_filtered",
+			"logstash_node_pipeline_events_out_total":       "logstash_node_stats_pipelines_events_out",
+			"logstash_node_plugin_events_in_total":          "logstash_node_stats_pipelines_vertices_events_in",
+			"logstash_node_plugin_events_out_total":         "logstash_node_stats_pipelines_vertices_events_out",
+			"logstash_node_queue_events":                    "logstash_node_stats_pipelines_queue_events_count",
+			"logstash_node_queue_size_bytes":                "logstash_node_stats_pipelines_queue_queue_size_in_bytes",
+			"logstash_node_queue_max_size_bytes":            "logstash_node_stats_pipelines_queue_max_queue_size_in_bytes",
+			// Metricbeat logstash 模块 node 的字段名
+			"logstash_info_node": "logstash_node_version",
+			"logstash_info_os":   "logstash_node_os",
+			"logstash_info_jvm":  "logstash_node_jvm_version",
+		},
+		Labels: map[string]string{
+			"pipeline":  "pipeline_id",
+			"plugin_id": "vertex_id",
+		},
+	},
+}
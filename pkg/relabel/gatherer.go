@@ -0,0 +1,163 @@
+package relabel
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	"google.golang.org/protobuf/proto"
+)
+
+// gatherer 在 Gather 的结果上执行重新标记
+type gatherer struct {
+	next  prometheus.Gatherer
+	rules Rules
+}
+
+// Gatherer 返回在 next 的结果上执行重新标记的 Gatherer，规则为空时直接返回 next
+func (rs Rules) Gatherer(next prometheus.Gatherer) prometheus.Gatherer {
+	if len(rs) == 0 {
+		return next
+	}
+	return &gatherer{next: next, rules: rs}
+}
+
+// Gather 实现了 prometheus.Gatherer 接口
+// 重新标记后指标名变化的序列会移动到对应的指标族；指标名不合法、重复和类型冲突的序列会被丢弃并作为错误返回，
+// 其余序列照常返回
+func (g *gatherer) Gather() ([]*dto.MetricFamily, error) {
+	families, err := g.next.Gather()
+	errs := prometheus.MultiError{}
+	if err != nil {
+		errs.Append(err)
+	}
+
+	byName := map[string]*dto.MetricFamily{}
+	seen := map[string]bool{}
+	invalid := map[string]bool{}
+	for _, family := range families {
+		for _, metric := range family.Metric {
+			labels := make(map[string]string, len(metric.Label)+1)
+			labels[MetricNameLabel] = family.GetName()
+			for _, pair := range metric.Label {
+				labels[pair.GetName()] = pair.GetValue()
+			}
+
+			labels, keep := g.rules.Process(labels)
+			if !keep {
+				continue
+			}
+			name := labels[MetricNameLabel]
+			if name == "" {
+				continue
+			}
+			// 文本格式只能表示传统的指标名，不合法的名称会让暴露失败或输出无法解析
+			if !model.IsValidLegacyMetricName(name) {
+				if !invalid[name] {
+					invalid[name] = true
+					errs.Append(fmt.Errorf("重新标记后的指标名 %q 不合法，已丢弃", name))
+				}
+				continue
+			}
+
+			target, ok := byName[name]
+			if !ok {
+				target = &dto.MetricFamily{
+					Name: proto.String(name),
+					Help: family.Help,
+					Type: family.Type,
+				}
+				// 改名后不再以单位结尾的指标不带单位元数据
+				if unitSuffixed(name, family) {
+					target.Unit = family.Unit
+				}
+				byName[name] = target
+			}
+			if target.GetType() != family.GetType() {
+				errs.Append(fmt.Errorf("重新标记后 %s 的序列类型 %s 与已有的 %s 冲突，已丢弃", name, family.GetType(), target.GetType()))
+				continue
+			}
+
+			relabeled := proto.Clone(metric).(*dto.Metric)
+			relabeled.Label = labelPairs(labels)
+
+			key := seriesKey(name, relabeled.Label)
+			if seen[key] {
+				errs.Append(fmt.Errorf("重新标记后出现重复的序列 %s，已丢弃", key))
+				continue
+			}
+			seen[key] = true
+			target.Metric = append(target.Metric, relabeled)
+		}
+	}
+
+	result := make([]*dto.MetricFamily, 0, len(byName))
+	for _, family := range byName {
+		if len(family.Metric) == 0 {
+			continue
+		}
+		sort.Slice(family.Metric, func(i, j int) bool {
+			return lessLabels(family.Metric[i].Label, family.Metric[j].Label)
+		})
+		result = append(result, family)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GetName() < result[j].GetName()
+	})
+
+	return result, errs.MaybeUnwrap()
+}
+
+// labelPairs 将标签转换为按名称排序的 LabelPair，丢弃 __ 开头的内部标签和空值标签
+func labelPairs(labels map[string]string) []*dto.LabelPair {
+	pairs := make([]*dto.LabelPair, 0, len(labels))
+	for name, value := range labels {
+		if strings.HasPrefix(name, "__") || value == "" {
+			continue
+		}
+		pairs = append(pairs, &dto.LabelPair{Name: proto.String(name), Value: proto.String(value)})
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].GetName() < pairs[j].GetName()
+	})
+	return pairs
+}
+
+// seriesKey 返回序列的唯一标识
+func seriesKey(name string, pairs []*dto.LabelPair) string {
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, pair := range pairs {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%q", pair.GetName(), pair.GetValue())
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// lessLabels 按标签名和取值依次比较两组标签
+func lessLabels(a, b []*dto.LabelPair) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].GetName() != b[i].GetName() {
+			return a[i].GetName() < b[i].GetName()
+		}
+		if a[i].GetValue() != b[i].GetValue() {
+			return a[i].GetValue() < b[i].GetValue()
+		}
+	}
+	return len(a) < len(b)
+}
+
+// unitSuffixed 判断 name（计数器去掉 _total 后）是否以 family 的单位结尾
+func unitSuffixed(name string, family *dto.MetricFamily) bool {
+	if family.GetType() == dto.MetricType_COUNTER {
+		name = strings.TrimSuffix(name, "_total")
+	}
+	return strings.HasSuffix(name, "_"+family.GetUnit())
+}
@@ -0,0 +1,198 @@
+// relabel 包实现了 Prometheus 风格的 metric_relabel_configs，在指标暴露前对时间序列重新标记
+// 支持的动作：replace、keep、drop、labelmap、labeldrop、hashmod
+package relabel
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/common/model"
+)
+
+// Action 是重新标记的动作
+type Action string
+
+// 支持的动作
+const (
+	Replace   Action = "replace"   // 用 replacement 设置 target_label
+	Keep      Action = "keep"      // 只保留 source_labels 匹配 regex 的序列
+	Drop      Action = "drop"      // 丢弃 source_labels 匹配 regex 的序列
+	LabelMap  Action = "labelmap"  // 将名称匹配 regex 的标签复制为 replacement 指定的新名称
+	LabelDrop Action = "labeldrop" // 删除名称匹配 regex 的标签
+	HashMod   Action = "hashmod"   // 将 source_labels 的哈希对 modulus 取模后写入 target_label
+)
+
+// 默认值与 Prometheus 一致
+const (
+	DefaultSeparator   = ";"
+	DefaultRegex       = "(.*)"
+	DefaultReplacement = "$1"
+)
+
+// MetricNameLabel 是保存指标名的特殊标签
+const MetricNameLabel = "__name__"
+
+// Config 是一条重新标记规则，未设置的字段使用 Prometheus 的默认值
+type Config struct {
+	SourceLabels []string // 取值来源的标签，按 Separator 连接
+	Separator    string   // 连接 SourceLabels 取值的分隔符
+	Regex        string   // 匹配的正则，自动锚定首尾
+	Modulus      uint64   // hashmod 的模数
+	TargetLabel  string   // replace 和 hashmod 写入的标签
+	Replacement  string   // replace 和 labelmap 使用的替换模板
+	Action       Action   // 动作，默认 replace
+}
+
+// labelNameRE 是合法的标签名
+var labelNameRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// rule 是编译后的重新标记规则
+type rule struct {
+	Config
+	regex *regexp.Regexp
+}
+
+// Rules 是编译后的一组重新标记规则，按顺序执行
+type Rules []*rule
+
+// Compile 校验并编译重新标记规则
+func Compile(configs []Config) (Rules, error) {
+	rules := make(Rules, 0, len(configs))
+	for i, c := range configs {
+		r, err := compile(c)
+		if err != nil {
+			return nil, fmt.Errorf("metric_relabel_configs[%d]: %v", i, err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// compile 填充默认值并校验单条规则
+func compile(c Config) (*rule, error) {
+	if c.Action == "" {
+		c.Action = Replace
+	}
+	c.Action = Action(strings.ToLower(string(c.Action)))
+	if c.Separator == "" {
+		c.Separator = DefaultSeparator
+	}
+	if c.Regex == "" {
+		c.Regex = DefaultRegex
+	}
+	if c.Replacement == "" && (c.Action == Replace || c.Action == LabelMap) {
+		c.Replacement = DefaultReplacement
+	}
+
+	regex, err := regexp.Compile("^(?:" + c.Regex + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("regex 无效: %v", err)
+	}
+
+	switch c.Action {
+	case Replace:
+		if c.TargetLabel == "" {
+			return nil, fmt.Errorf("replace 动作需要 target_label")
+		}
+		// 不引用捕获组的指标名在编译时就能确定，含有引用的在收集时检查
+		if c.TargetLabel == MetricNameLabel && !strings.Contains(c.Replacement, "$") && !model.IsValidLegacyMetricName(c.Replacement) {
+			return nil, fmt.Errorf("replacement %q 不是合法的指标名", c.Replacement)
+		}
+	case HashMod:
+		if c.TargetLabel == "" {
+			return nil, fmt.Errorf("hashmod 动作需要 target_label")
+		}
+		if !labelNameRE.MatchString(c.TargetLabel) {
+			return nil, fmt.Errorf("target_label %q 不合法", c.TargetLabel)
+		}
+		if c.Modulus == 0 {
+			return nil, fmt.Errorf("hashmod 动作需要大于 0 的 modulus")
+		}
+	case Keep, Drop:
+		if len(c.SourceLabels) == 0 {
+			return nil, fmt.Errorf("%s 动作需要 source_labels", c.Action)
+		}
+	case LabelMap, LabelDrop:
+	default:
+		return nil, fmt.Errorf("未知的动作: %s", c.Action)
+	}
+
+	for _, name := range c.SourceLabels {
+		if !labelNameRE.MatchString(name) {
+			return nil, fmt.Errorf("source_labels 中的标签名 %q 不合法", name)
+		}
+	}
+
+	return &rule{Config: c, regex: regex}, nil
+}
+
+// Process 依次执行所有规则，返回处理后的标签以及是否保留该序列
+// labels 包含 __name__，调用方不应再使用传入的 map
+func (rs Rules) Process(labels map[string]string) (map[string]string, bool) {
+	for _, r := range rs {
+		if !r.apply(labels) {
+			return nil, false
+		}
+	}
+	return labels, true
+}
+
+// apply 执行单条规则，返回 false 表示丢弃序列
+func (r *rule) apply(labels map[string]string) bool {
+	values := make([]string, len(r.SourceLabels))
+	for i, name := range r.SourceLabels {
+		values[i] = labels[name]
+	}
+	value := strings.Join(values, r.Separator)
+
+	switch r.Action {
+	case Keep:
+		return r.regex.MatchString(value)
+
+	case Drop:
+		return !r.regex.MatchString(value)
+
+	case Replace:
+		indexes := r.regex.FindStringSubmatchIndex(value)
+		if indexes == nil {
+			return true
+		}
+		target := string(r.regex.ExpandString(nil, r.TargetLabel, value, indexes))
+		if !labelNameRE.MatchString(target) {
+			return true
+		}
+		result := string(r.regex.ExpandString(nil, r.Replacement, value, indexes))
+		if result == "" {
+			delete(labels, target)
+		} else {
+			labels[target] = result
+		}
+
+	case HashMod:
+		sum := md5.Sum([]byte(value))
+		labels[r.TargetLabel] = fmt.Sprint(binary.BigEndian.Uint64(sum[8:]) % r.Modulus)
+
+	case LabelMap:
+		mapped := map[string]string{}
+		for name, v := range labels {
+			if r.regex.MatchString(name) {
+				mapped[r.regex.ReplaceAllString(name, r.Replacement)] = v
+			}
+		}
+		for name, v := range mapped {
+			labels[name] = v
+		}
+
+	case LabelDrop:
+		for name := range labels {
+			if r.regex.MatchString(name) {
+				delete(labels, name)
+			}
+		}
+	}
+
+	return true
+}
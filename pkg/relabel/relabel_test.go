@@ -0,0 +1,216 @@
+package relabel
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCompile(t *testing.T) {
+	cases := []struct {
+		name   string
+		config Config
+		err    string // 为空表示应当编译成功
+	}{
+		{"默认 replace", Config{SourceLabels: []string{"a"}, TargetLabel: "b"}, ""},
+		{"动作不区分大小写", Config{SourceLabels: []string{"a"}, Action: "LabelDrop", Regex: "a"}, ""},
+		{"replace 缺少 target_label", Config{SourceLabels: []string{"a"}}, "target_label"},
+		{"hashmod 缺少 modulus", Config{SourceLabels: []string{"a"}, TargetLabel: "b", Action: "hashmod"}, "modulus"},
+		{"keep 缺少 source_labels", Config{Action: "keep"}, "source_labels"},
+		{"未知动作", Config{Action: "rename"}, "rename"},
+		{"非法正则", Config{SourceLabels: []string{"a"}, TargetLabel: "b", Regex: "("}, "metric_relabel_configs[0]"},
+		{"不合法的指标名", Config{TargetLabel: MetricNameLabel, Replacement: "bad-name"}, "bad-name"},
+		{"引用捕获组的指标名在收集时检查", Config{SourceLabels: []string{MetricNameLabel}, TargetLabel: MetricNameLabel, Replacement: "x-$1"}, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := Compile([]Config{c.config})
+			switch {
+			case c.err == "" && err != nil:
+				t.Errorf("意外的错误: %v", err)
+			case c.err != "" && err == nil:
+				t.Errorf("应当报错（%s）", c.err)
+			case c.err != "" && !strings.Contains(err.Error(), c.err):
+				t.Errorf("错误 %q 未包含 %q", err, c.err)
+			}
+		})
+	}
+}
+
+func TestProcess(t *testing.T) {
+	input := map[string]string{
+		MetricNameLabel: "logstash_pipeline_events_in_total",
+		"pipeline":      "main",
+		"instance":      "ls-1",
+	}
+
+	cases := []struct {
+		name   string
+		config Config
+		want   map[string]string // nil 表示序列被丢弃
+	}{
+		{
+			"replace 改写指标名",
+			Config{SourceLabels: []string{MetricNameLabel}, Regex: "logstash_(.*)", TargetLabel: MetricNameLabel, Replacement: "ls_$1"},
+			map[string]string{MetricNameLabel: "ls_pipeline_events_in_total", "pipeline": "main", "instance": "ls-1"},
+		},
+		{
+			"replace 未匹配时保持不变",
+			Config{SourceLabels: []string{"pipeline"}, Regex: "other", TargetLabel: "env", Replacement: "x"},
+			input,
+		},
+		{
+			"replace 结果为空时删除标签",
+			Config{SourceLabels: []string{"pipeline"}, Regex: "main()", TargetLabel: "instance"},
+			map[string]string{MetricNameLabel: "logstash_pipeline_events_in_total", "pipeline": "main"},
+		},
+		{
+			"keep 匹配",
+			Config{SourceLabels: []string{"pipeline"}, Regex: "ma.*", Action: Keep},
+			input,
+		},
+		{
+			"keep 正则锚定首尾",
+			Config{SourceLabels: []string{"pipeline"}, Regex: "ma", Action: Keep},
+			nil,
+		},
+		{
+			"drop 多个源标签以分隔符连接",
+			Config{SourceLabels: []string{"pipeline", "instance"}, Regex: "main;ls-1", Action: Drop},
+			nil,
+		},
+		{
+			"labelmap",
+			Config{Regex: "pipe(.*)", Replacement: "p$1", Action: LabelMap},
+			map[string]string{MetricNameLabel: "logstash_pipeline_events_in_total", "pipeline": "main", "pline": "main", "instance": "ls-1"},
+		},
+		{
+			"labeldrop",
+			Config{Regex: "inst.*", Action: LabelDrop},
+			map[string]string{MetricNameLabel: "logstash_pipeline_events_in_total", "pipeline": "main"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rules, err := Compile([]Config{c.config})
+			if err != nil {
+				t.Fatal(err)
+			}
+			labels := map[string]string{}
+			for k, v := range input {
+				labels[k] = v
+			}
+
+			got, keep := rules.Process(labels)
+			if c.want == nil {
+				if keep {
+					t.Errorf("序列应被丢弃，得到 %v", got)
+				}
+				return
+			}
+			if !keep || !reflect.DeepEqual(got, c.want) {
+				t.Errorf("得到 %v（保留 %v），期望 %v", got, keep, c.want)
+			}
+		})
+	}
+}
+
+// hashmod 的结果稳定且小于 modulus
+func TestHashMod(t *testing.T) {
+	rules, err := Compile([]Config{{SourceLabels: []string{"instance"}, TargetLabel: "shard", Modulus: 4, Action: "HASHMOD"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, _ := rules.Process(map[string]string{"instance": "ls-1"})
+	second, _ := rules.Process(map[string]string{"instance": "ls-1"})
+	if first["shard"] != second["shard"] {
+		t.Errorf("同一输入的分片不一致: %s, %s", first["shard"], second["shard"])
+	}
+	if s := first["shard"]; len(s) != 1 || s < "0" || s > "3" {
+		t.Errorf("分片 %q 超出范围", s)
+	}
+}
+
+func TestGatherer(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	events := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "logstash_events_total", Help: "事件数"}, []string{"pipeline"})
+	events.WithLabelValues("main").Add(3)
+	events.WithLabelValues("debug").Add(1)
+	registry.MustRegister(events)
+	up := prometheus.NewGauge(prometheus.GaugeOpts{Name: "logstash_up", Help: "是否可达"})
+	up.Set(1)
+	registry.MustRegister(up)
+
+	rules, err := Compile([]Config{
+		{SourceLabels: []string{"pipeline"}, Regex: "debug", Action: Drop},
+		{SourceLabels: []string{MetricNameLabel}, Regex: "logstash_(.*)", TargetLabel: MetricNameLabel, Replacement: "ls_$1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := rules.Gatherer(registry).Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, family := range families {
+		names = append(names, family.GetName())
+	}
+	if want := []string{"ls_events_total", "ls_up"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("指标族 %v，期望 %v", names, want)
+	}
+	if n := len(families[0].Metric); n != 1 {
+		t.Errorf("debug pipeline 应被丢弃，得到 %d 条序列", n)
+	}
+}
+
+// 改名后重复的序列被丢弃并作为错误返回，其余序列照常返回
+func TestGathererDuplicates(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	events := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "logstash_events", Help: "事件数"}, []string{"pipeline"})
+	events.WithLabelValues("a").Set(1)
+	events.WithLabelValues("b").Set(2)
+	registry.MustRegister(events)
+
+	rules, err := Compile([]Config{{Regex: "pipeline", Action: LabelDrop}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := rules.Gatherer(registry).Gather()
+	if err == nil {
+		t.Error("重复的序列应当返回错误")
+	}
+	if len(families) != 1 || len(families[0].Metric) != 1 {
+		t.Errorf("应保留一条序列，得到 %v", families)
+	}
+}
+
+// 改写后的指标名不合法时丢弃序列并返回错误，其余序列照常返回
+func TestGathererInvalidName(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	events := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "logstash_events", Help: "事件数"}, []string{"pipeline"})
+	events.WithLabelValues("main").Set(1)
+	events.WithLabelValues("9lives").Set(2)
+	registry.MustRegister(events)
+
+	// pipeline 为 9lives 的序列改名为以数字开头的指标名
+	rules, err := Compile([]Config{{SourceLabels: []string{"pipeline"}, Regex: "(9.*)", TargetLabel: MetricNameLabel, Replacement: "$1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	families, err := rules.Gatherer(registry).Gather()
+	if err == nil || !strings.Contains(err.Error(), "9lives") {
+		t.Errorf("不合法的指标名应当返回错误: %v", err)
+	}
+	if len(families) != 1 || families[0].GetName() != "logstash_events" || len(families[0].Metric) != 1 {
+		t.Errorf("应只保留 main 的序列，得到 %v", families)
+	}
+}
@@ -2,34 +2,220 @@ package server
 
 import (
 	"fmt"
+	"reflect"
+	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
 )
 
 // LogstashConfig 配置文件结构
 type LogstashConfig struct {
-	Endpoints []string `mapstructure:"endpoints"` // Logstash API 地址列表
+	Endpoints []EndpointConfig `mapstructure:"endpoints"` // Logstash 端点列表，元素可以是地址字符串或端点配置
 	Web struct {
 		ListenAddress string `mapstructure:"listen_address"` // Web 监听地址
 	} `mapstructure:"web"`
+
+	Timeout        time.Duration        `mapstructure:"timeout"`         // 单次抓取超时时间，重试也必须在此时间内完成
+	Retry          RetryConfig          `mapstructure:"retry"`           // 瞬时错误重试配置
+	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"` // 端点熔断器配置
+	MaxBodySize    int64                `mapstructure:"max_body_size"`   // 解压后响应体的最大字节数，0 表示不限制
+	NodeInfoTTL    time.Duration        `mapstructure:"node_info_ttl"`   // 节点信息缓存有效期，0 表示不缓存
+	Transport      TransportConfig      `mapstructure:"transport"`       // 录制和回放传输配置
+	Strict         bool                 `mapstructure:"strict"`          // 严格模式：校验指标描述，注册失败时退出
+	Collectors     map[string]bool      `mapstructure:"collectors"`      // 全局子收集器开关，未设置的使用默认值
+
+	MaxSeriesPerMetric int `mapstructure:"max_series_per_metric"` // 单个插件指标在单个实例上的序列上限，0 表示不限制
+
+	Poll               PollConfig    `mapstructure:"poll"`                 // 后台轮询配置
+	MinRefreshInterval time.Duration `mapstructure:"min_refresh_interval"` // 同步模式下请求 Logstash 的最小间隔，0 表示只合并并发抓取
+	MaxConcurrency     int           `mapstructure:"max_concurrency"`      // 所有端点同时执行的子收集器数量上限，0 表示不限制
+
+	Modules map[string]ModuleConfig `mapstructure:"modules"` // /probe 使用的模块，按名称引用
+
+	Labels map[string]string `mapstructure:"labels"` // 所有端点默认的常量标签
+
+	InstanceLabel        string          `mapstructure:"instance_label"`         // 标识 Logstash 节点的标签名，默认 instance
+	MetricRelabelConfigs []RelabelConfig `mapstructure:"metric_relabel_configs"` // 暴露前对序列执行的重新标记规则
+
+	Naming NamingConfig `mapstructure:"naming"` // 指标命名兼容配置
+
+	Clusters map[string]ClusterConfig `mapstructure:"clusters"` // 集群配置，端点通过 cluster 引用
+
+	WatchConfig bool `mapstructure:"watch_config"` // 配置文件变化时自动重新加载
+
+	Auth AuthConfig `mapstructure:"auth"` // 所有端点默认的认证信息，端点和模块可以覆盖
+	TLS  TLSConfig  `mapstructure:"tls"`  // 所有端点默认的 TLS 参数，端点和模块可以覆盖
+}
+
+// ClusterConfig 集群配置
+type ClusterConfig struct {
+	MinHealthy int `mapstructure:"min_healthy"` // 集群健康所需的最少可用节点数，0 表示所有节点
+}
+
+// NamingConfig 指标命名兼容配置
+type NamingConfig struct {
+	Profile  string `mapstructure:"profile"`   // 命名配置：native、kuskoman 或 metricbeat
+	DualEmit bool   `mapstructure:"dual_emit"` // 同时暴露原名和兼容名称的指标
+}
+
+// RelabelConfig 单条重新标记规则，语义与 Prometheus 的 metric_relabel_configs 一致
+type RelabelConfig struct {
+	SourceLabels []string `mapstructure:"source_labels"` // 源标签，取值用 separator 连接后与 regex 匹配
+	Separator    string   `mapstructure:"separator"`     // 源标签取值的分隔符，默认 ;
+	Regex        string   `mapstructure:"regex"`         // 完整匹配的正则表达式，默认 (.*)
+	Modulus      uint64   `mapstructure:"modulus"`       // hashmod 的模数
+	TargetLabel  string   `mapstructure:"target_label"`  // replace 和 hashmod 写入的标签
+	Replacement  string   `mapstructure:"replacement"`   // replace 和 labelmap 的替换模板，默认 $1
+	Action       string   `mapstructure:"action"`        // replace、keep、drop、labelmap、labeldrop 或 hashmod，默认 replace
+}
+
+// ModuleConfig /probe 模块配置，未设置的项使用全局配置
+type ModuleConfig struct {
+	Timeout    time.Duration   `mapstructure:"timeout"`    // 单次探测超时时间
+	Auth       AuthConfig      `mapstructure:"auth"`       // 认证信息
+	TLS        TLSConfig       `mapstructure:"tls"`        // TLS 参数
+	Collectors map[string]bool `mapstructure:"collectors"` // 子收集器开关，覆盖全局开关
+	Filters    FiltersConfig   `mapstructure:"filters"`    // pipeline 和插件过滤规则
+}
+
+// AuthConfig 访问 Logstash API 的认证信息，同时设置时优先使用 Basic 认证
+type AuthConfig struct {
+	Username    string `mapstructure:"username"`     // Basic 认证用户名
+	Password    string `mapstructure:"password"`     // Basic 认证密码
+	BearerToken string `mapstructure:"bearer_token"` // Bearer Token
+}
+
+// TLSConfig 访问 HTTPS Logstash API 的 TLS 参数
+type TLSConfig struct {
+	CAFile             string `mapstructure:"ca_file"`              // CA 证书文件
+	CertFile           string `mapstructure:"cert_file"`            // 客户端证书文件
+	KeyFile            string `mapstructure:"key_file"`             // 客户端私钥文件
+	ServerName         string `mapstructure:"server_name"`          // 校验服务端证书时使用的主机名
+	InsecureSkipVerify bool   `mapstructure:"insecure_skip_verify"` // 跳过服务端证书校验
+}
+
+// PollConfig 后台轮询配置
+type PollConfig struct {
+	Interval time.Duration `mapstructure:"interval"` // 轮询间隔，0 表示每次抓取同步请求 Logstash
+	MaxAge   time.Duration `mapstructure:"max_age"`  // 快照最大年龄，超过后不再导出快照中的指标，0 表示不限制
+}
+
+// EndpointConfig 单个 Logstash 端点配置，未设置的项使用全局配置
+type EndpointConfig struct {
+	Name       string          `mapstructure:"name"`       // 实例标签的取值，为空时使用地址中的 host:port
+	URL        string          `mapstructure:"url"`        // Logstash API 地址
+	Timeout    time.Duration   `mapstructure:"timeout"`    // 单次抓取超时时间，覆盖全局 timeout
+	Auth       AuthConfig      `mapstructure:"auth"`       // 认证信息，覆盖全局 auth
+	TLS        TLSConfig       `mapstructure:"tls"`        // TLS 参数，覆盖全局 tls
+	Collectors map[string]bool `mapstructure:"collectors"` // 端点级子收集器开关，覆盖全局开关
+	Filters    FiltersConfig   `mapstructure:"filters"`    // pipeline 和插件过滤规则
+
+	PollInterval time.Duration `mapstructure:"poll_interval"` // 端点级轮询（抓取）间隔，覆盖全局 poll.interval
+
+	Labels map[string]string `mapstructure:"labels"` // 端点的常量标签，覆盖全局同名标签
+
+	Cluster string `mapstructure:"cluster"` // 所属集群名称，为空表示不属于任何集群
+}
+
+// FiltersConfig pipeline 和插件过滤规则，正则需要匹配完整的取值，exclude 优先于 include
+type FiltersConfig struct {
+	Pipelines       MatchConfig `mapstructure:"pipelines"`        // 按 pipeline ID 过滤
+	PluginTypes     MatchConfig `mapstructure:"plugin_types"`     // 按插件类型（input、filter、output）过滤
+	PluginNames     MatchConfig `mapstructure:"plugin_names"`     // 按插件名称过滤
+	PluginIDs       MatchConfig `mapstructure:"plugin_ids"`       // 按插件 ID 过滤
+	CollapsePlugins bool        `mapstructure:"collapse_plugins"` // 按 pipeline 和插件类型聚合插件指标
+}
+
+// MatchConfig include/exclude 正则
+type MatchConfig struct {
+	Include string `mapstructure:"include"` // 只保留匹配的取值，为空表示全部保留
+	Exclude string `mapstructure:"exclude"` // 排除匹配的取值
+}
+
+// endpointDecodeHook 允许 endpoints 中直接写地址字符串，等价于只设置了 url 的端点配置
+func endpointDecodeHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf(EndpointConfig{}) {
+		return data, nil
+	}
+	return EndpointConfig{URL: data.(string)}, nil
+}
+
+// TransportConfig 录制和回放传输配置
+type TransportConfig struct {
+	Mode        string `mapstructure:"mode"`         // 传输模式：live、record 或 replay
+	FixturesDir string `mapstructure:"fixtures_dir"` // 录制和回放使用的 fixtures 目录
 }
 
-// LoadConfig 从文件加载配置
+// RetryConfig 瞬时错误重试配置
+type RetryConfig struct {
+	MaxAttempts    int           `mapstructure:"max_attempts"`    // 最大尝试次数（包含第一次请求）
+	InitialBackoff time.Duration `mapstructure:"initial_backoff"` // 第一次重试前的基础退避时间
+	MaxBackoff     time.Duration `mapstructure:"max_backoff"`     // 单次退避时间上限
+}
+
+// CircuitBreakerConfig 端点熔断器配置
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `mapstructure:"failure_threshold"` // 连续失败多少次后打开熔断器，0 表示禁用
+	OpenTimeout      time.Duration `mapstructure:"open_timeout"`      // 熔断器打开后多久进行半开探测
+}
+
+// setDefaults 设置配置项的默认值
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("timeout", "10s")
+	v.SetDefault("retry.max_attempts", 3)
+	v.SetDefault("retry.initial_backoff", "200ms")
+	v.SetDefault("retry.max_backoff", "2s")
+	v.SetDefault("circuit_breaker.failure_threshold", 5)
+	v.SetDefault("circuit_breaker.open_timeout", "30s")
+	v.SetDefault("max_body_size", 32<<20)
+	v.SetDefault("node_info_ttl", "5m")
+	v.SetDefault("transport.mode", "live")
+	v.SetDefault("transport.fixtures_dir", "fixtures")
+	v.SetDefault("poll.interval", "0s")
+	v.SetDefault("poll.max_age", "5m")
+	v.SetDefault("instance_label", "instance")
+	v.SetDefault("naming.profile", "native")
+}
+
+// LoadConfig 加载配置，优先级从高到低为环境变量、配置文件、默认值
+// filename 为空时不读取配置文件，只使用环境变量和默认值
 func LoadConfig(filename string) (*LogstashConfig, error) {
+	return loadConfig(filename, true)
+}
+
+// LoadConfigFile 只从配置文件和默认值加载配置，不读取环境变量，用于校验配置文件本身
+func LoadConfigFile(filename string) (*LogstashConfig, error) {
+	return loadConfig(filename, false)
+}
+
+// loadConfig 加载配置，env 为 false 时忽略环境变量
+func loadConfig(filename string, env bool) (*LogstashConfig, error) {
 	v := viper.New()
+	setDefaults(v)
+	if env {
+		bindEnv(v)
+	}
 
-	// 设置配置文件路径
-	v.SetConfigFile(filename)
+	if filename != "" {
+		// 设置配置文件路径
+		v.SetConfigFile(filename)
 
-	// 读取配置文件
-	if err := v.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+		// 读取配置文件
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("读取配置文件失败: %v", err)
+		}
 	}
 
 	var config LogstashConfig
-	if err := v.Unmarshal(&config); err != nil {
+	hook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		endpointsDecodeHook,
+		endpointDecodeHook,
+		mapstructure.StringToTimeDurationHookFunc(),
+	))
+	if err := v.Unmarshal(&config, hook); err != nil {
 		return nil, fmt.Errorf("解析配置文件失败: %v", err)
 	}
 
 	return &config, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DriftFunc 返回集群的配置漂移报告，cluster 为空时返回所有集群的报告，集群不存在时返回 false
+type DriftFunc func(cluster string) (interface{}, bool)
+
+// SetDrift 启用 /drift 接口，需要在 SetupRoutes 之前调用，之后可以在运行时替换
+func (s *Server) SetDrift(drift DriftFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.drift = drift
+}
+
+// handleDrift 处理 /drift?cluster= 请求，以 JSON 返回各节点的配置差异
+func (s *Server) handleDrift(c *gin.Context) {
+	s.mu.RLock()
+	drift := s.drift
+	s.mu.RUnlock()
+
+	report, ok := drift(c.Query("cluster"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "集群不存在: " + c.Query("cluster")})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix 是覆盖配置项的环境变量前缀，例如 LOGSTASH_EXPORTER_WEB_LISTEN_ADDRESS 覆盖 web.listen_address
+const EnvPrefix = "LOGSTASH_EXPORTER"
+
+// envName 返回配置项对应的环境变量名
+func envName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// bindEnv 为 LogstashConfig 的配置项绑定环境变量，环境变量优先于配置文件：
+//   - 标量配置项（字符串、数字、布尔、时长）直接绑定，例如 LOGSTASH_EXPORTER_RETRY_MAX_ATTEMPTS
+//   - endpoints 绑定 LOGSTASH_EXPORTER_ENDPOINTS，取值是逗号分隔的地址列表
+//   - 取值为标量的 map（labels、collectors）按环境中已有的变量绑定，例如 LOGSTASH_EXPORTER_LABELS_ENV 设置 labels.env
+//
+// 其余结构化的配置项（modules、clusters、metric_relabel_configs）只能在配置文件中设置
+func bindEnv(v *viper.Viper) {
+	bindEnvFields(v, "", reflect.TypeOf(LogstashConfig{}))
+}
+
+// bindEnvFields 递归绑定结构体 t 中的配置项
+func bindEnvFields(v *viper.Viper, prefix string, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("mapstructure"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		field := t.Field(i).Type
+		switch {
+		case field == reflect.TypeOf(time.Duration(0)):
+			v.BindEnv(key, envName(key))
+		case field.Kind() == reflect.Struct:
+			bindEnvFields(v, key, field)
+		case field.Kind() == reflect.Map:
+			if scalarKind(field.Elem().Kind()) {
+				bindEnvMap(v, key)
+			}
+		case field.Kind() == reflect.Slice:
+			if field.Elem() == reflect.TypeOf(EndpointConfig{}) || scalarKind(field.Elem().Kind()) {
+				v.BindEnv(key, envName(key))
+			}
+		case scalarKind(field.Kind()):
+			v.BindEnv(key, envName(key))
+		}
+	}
+}
+
+// bindEnvMap 将环境中以 map 配置项为前缀的变量绑定为 map 的元素，键名转为小写
+func bindEnvMap(v *viper.Viper, key string) {
+	prefix := envName(key) + "_"
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+		v.BindEnv(key+"."+strings.ToLower(strings.TrimPrefix(name, prefix)), name)
+	}
+}
+
+// scalarKind 判断是否是可以直接从环境变量字符串解码的类型
+func scalarKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// endpointsDecodeHook 将逗号分隔的地址字符串（来自 LOGSTASH_EXPORTER_ENDPOINTS）拆分为地址列表
+func endpointsDecodeHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf([]EndpointConfig{}) {
+		return data, nil
+	}
+
+	var endpoints []string
+	for _, endpoint := range strings.Split(data.(string), ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints, nil
+}
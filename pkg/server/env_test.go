@@ -0,0 +1,83 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// 环境变量优先于配置文件，配置文件优先于默认值
+func TestEnvPrecedence(t *testing.T) {
+	const content = `endpoints:
+  - http://file:9600
+timeout: 5s
+labels:
+  env: prod
+  team: logs
+collectors:
+  info: false
+`
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		check func(c *LogstashConfig) (got, want interface{})
+	}{
+		{"配置文件覆盖默认值", nil, func(c *LogstashConfig) (interface{}, interface{}) {
+			return c.Timeout, 5 * time.Second
+		}},
+		{"环境变量覆盖配置文件", map[string]string{"LOGSTASH_EXPORTER_TIMEOUT": "7s"}, func(c *LogstashConfig) (interface{}, interface{}) {
+			return c.Timeout, 7 * time.Second
+		}},
+		{"环境变量覆盖默认值", map[string]string{"LOGSTASH_EXPORTER_RETRY_MAX_ATTEMPTS": "1"}, func(c *LogstashConfig) (interface{}, interface{}) {
+			return c.Retry.MaxAttempts, 1
+		}},
+		{"嵌套的配置项", map[string]string{"LOGSTASH_EXPORTER_WEB_LISTEN_ADDRESS": ":9999"}, func(c *LogstashConfig) (interface{}, interface{}) {
+			return c.Web.ListenAddress, ":9999"
+		}},
+		{"端点列表替换配置文件中的端点", map[string]string{"LOGSTASH_EXPORTER_ENDPOINTS": "http://a:9600, http://b:9600"}, func(c *LogstashConfig) (interface{}, interface{}) {
+			urls := make([]string, len(c.Endpoints))
+			for i, ep := range c.Endpoints {
+				urls[i] = ep.URL
+			}
+			return urls, []string{"http://a:9600", "http://b:9600"}
+		}},
+		{"map 元素与配置文件合并", map[string]string{"LOGSTASH_EXPORTER_LABELS_ENV": "staging", "LOGSTASH_EXPORTER_LABELS_ZONE": "a"}, func(c *LogstashConfig) (interface{}, interface{}) {
+			return c.Labels, map[string]string{"env": "staging", "team": "logs", "zone": "a"}
+		}},
+		{"布尔 map 元素", map[string]string{"LOGSTASH_EXPORTER_COLLECTORS_INFO": "true"}, func(c *LogstashConfig) (interface{}, interface{}) {
+			return c.Collectors, map[string]bool{"info": true}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			config, err := LoadConfig(writeTestConfig(t, content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := tt.check(config); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// 没有配置文件时只使用环境变量和默认值
+func TestEnvWithoutConfigFile(t *testing.T) {
+	t.Setenv("LOGSTASH_EXPORTER_ENDPOINTS", "http://a:9600")
+
+	config, err := LoadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(config.Endpoints) != 1 || config.Endpoints[0].URL != "http://a:9600" {
+		t.Errorf("endpoints = %v, want [http://a:9600]", config.Endpoints)
+	}
+	if config.Timeout != 10*time.Second {
+		t.Errorf("timeout = %s, want 默认值 10s", config.Timeout)
+	}
+}
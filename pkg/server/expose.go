@@ -0,0 +1,60 @@
+package server
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+)
+
+// exposeHandler 按客户端协商的格式暴露 g 收集到的指标
+// 与 promhttp.HandlerFor 不同，OpenMetrics 格式会输出 # UNIT 元数据
+func exposeHandler(g prometheus.Gatherer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		families, err := g.Gather()
+		if err != nil {
+			errorLogger{}.Println("收集指标失败:", err)
+			http.Error(w, fmt.Sprintf("收集指标失败: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		format := expfmt.NegotiateIncludingOpenMetrics(r.Header)
+		w.Header().Set("Content-Type", string(format))
+
+		var out io.Writer = w
+		if acceptsGzip(r) {
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			defer gz.Close()
+			out = gz
+		}
+
+		enc := expfmt.NewEncoder(out, format, expfmt.WithUnit())
+		for _, family := range families {
+			if err := enc.Encode(family); err != nil {
+				errorLogger{}.Println("编码指标失败:", err)
+				return
+			}
+		}
+		if closer, ok := enc.(expfmt.Closer); ok {
+			if err := closer.Close(); err != nil {
+				errorLogger{}.Println("编码指标失败:", err)
+			}
+		}
+	})
+}
+
+// acceptsGzip 判断客户端是否接受 gzip 压缩
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		encoding, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(encoding, "gzip") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ProbeCollector 是 /probe 为单个目标创建的收集器
+type ProbeCollector interface {
+	prometheus.Collector
+	Succeeded() bool // 最近一次收集是否成功
+}
+
+// ProbeFunc 根据目标地址和模块名创建收集器，模块不存在或目标无效时返回错误
+type ProbeFunc func(target, module string) (ProbeCollector, error)
+
+// SetProbe 启用 /probe 接口，需要在 SetupRoutes 之前调用，之后可以在运行时替换
+func (s *Server) SetProbe(probe ProbeFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.probe = probe
+}
+
+// handleProbe 处理 /probe?target=&module= 请求
+// 每个请求使用新的注册表，只包含该目标的指标以及 probe_success、probe_duration_seconds
+func (s *Server) handleProbe(c *gin.Context) {
+	target := c.Query("target")
+	if target == "" {
+		c.String(http.StatusBadRequest, "缺少 target 参数")
+		return
+	}
+	module := c.DefaultQuery("module", "default")
+
+	s.mu.RLock()
+	probe := s.probe
+	s.mu.RUnlock()
+
+	collector, err := probe(target, module)
+	if err != nil {
+		c.String(http.StatusBadRequest, fmt.Sprintf("无法探测 %s: %v", target, err))
+		return
+	}
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(collector); err != nil {
+		c.String(http.StatusInternalServerError, fmt.Sprintf("注册收集器失败: %v", err))
+		return
+	}
+
+	// probe 指标在目标注册表之后收集：Gatherers 按顺序收集，此时目标已经抓取完毕
+	begin := time.Now()
+	probeRegistry := prometheus.NewRegistry()
+	probeRegistry.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "probe_success",
+			Help: "Whether the Logstash probe succeeded.",
+		}, func() float64 {
+			if collector.Succeeded() {
+				return 1
+			}
+			return 0
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "probe_duration_seconds",
+			Help: "Duration of the Logstash probe in seconds.",
+		}, func() float64 {
+			return time.Since(begin).Seconds()
+		}),
+	)
+
+	// 变换只作用于目标的指标，probe_success 和 probe_duration_seconds 保持不变
+	handler := exposeHandler(prometheus.Gatherers{s.gatherer(registry), probeRegistry})
+	handler.ServeHTTP(c.Writer, c.Request)
+}
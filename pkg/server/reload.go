@@ -0,0 +1,24 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReloadFunc 重新加载配置，失败时返回错误并继续使用当前配置
+type ReloadFunc func() error
+
+// SetReload 启用 POST /-/reload 接口，需要在 SetupRoutes 之前调用
+func (s *Server) SetReload(reload ReloadFunc) {
+	s.reload = reload
+}
+
+// handleReload 处理 POST /-/reload 请求
+func (s *Server) handleReload(c *gin.Context) {
+	if err := s.reload(); err != nil {
+		c.String(http.StatusInternalServerError, "重新加载配置失败: %v\n", err)
+		return
+	}
+	c.String(http.StatusOK, "重新加载配置成功\n")
+}
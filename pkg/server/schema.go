@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// durationPattern 匹配 time.ParseDuration 接受的时长，例如 10s、1m30s
+const durationPattern = `^(0|([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$`
+
+// schemaEnums 是取值有限的配置项，键是去掉数组下标后的配置项路径
+var schemaEnums = map[string][]string{
+	"transport.mode":                {"live", "record", "replay"},
+	"metric_relabel_configs.action": {"replace", "keep", "drop", "labelmap", "labeldrop", "hashmod"},
+}
+
+// caseInsensitiveEnums 是取值不区分大小写的配置项，例如重新标记的 action 在编译时转为小写
+var caseInsensitiveEnums = map[string]bool{
+	"metric_relabel_configs.action": true,
+}
+
+// ConfigSchema 根据 LogstashConfig 生成配置文件的 JSON Schema（draft-07）
+// 未知的配置项视为错误；endpoints 的元素可以是地址字符串，也可以是端点配置
+// names 是 naming.profile 的可选值，为空时不限制
+func ConfigSchema(names []string) map[string]interface{} {
+	schema := typeSchema("", reflect.TypeOf(LogstashConfig{}))
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "go-logstash-exporter 配置文件"
+
+	if len(names) > 0 {
+		properties := schema["properties"].(map[string]interface{})
+		naming := properties["naming"].(map[string]interface{})["properties"].(map[string]interface{})
+		naming["profile"].(map[string]interface{})["enum"] = names
+	}
+	return schema
+}
+
+// ConfigSchemaJSON 返回缩进格式的 JSON Schema
+func ConfigSchemaJSON(names []string) ([]byte, error) {
+	data, err := json.MarshalIndent(ConfigSchema(names), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// typeSchema 返回类型 t 的 JSON Schema，path 用于查找取值范围
+func typeSchema(path string, t reflect.Type) map[string]interface{} {
+	switch {
+	case t == reflect.TypeOf(time.Duration(0)):
+		// 时长可以写成字符串，也可以写成纳秒数
+		// pattern 只约束字符串，minimum 只约束整数
+		return map[string]interface{}{
+			"type":    []string{"string", "integer"},
+			"pattern": durationPattern,
+			"minimum": 0,
+		}
+	case t == reflect.TypeOf(EndpointConfig{}):
+		endpoint := structSchema(path, t)
+		endpoint["required"] = []string{"url"}
+		return map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]interface{}{"type": "string", "description": "Logstash API 地址"},
+				endpoint,
+			},
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return structSchema(path, t)
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": typeSchema(path, t.Elem()),
+		}
+	case reflect.Slice:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": typeSchema(path, t.Elem()),
+		}
+	case reflect.String:
+		schema := map[string]interface{}{"type": "string"}
+		enum, ok := schemaEnums[path]
+		switch {
+		case ok && caseInsensitiveEnums[path]:
+			// enum 供编辑器补全，pattern 接受任意大小写
+			schema["anyOf"] = []interface{}{
+				map[string]interface{}{"enum": enum},
+				map[string]interface{}{"pattern": caseInsensitivePattern(enum)},
+			}
+		case ok:
+			schema["enum"] = enum
+		}
+		return schema
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+	return map[string]interface{}{}
+}
+
+// caseInsensitivePattern 返回不区分大小写地匹配 values 之一的正则，JSON Schema 的正则不支持 (?i)
+func caseInsensitivePattern(values []string) string {
+	alternatives := make([]string, len(values))
+	for i, value := range values {
+		var b strings.Builder
+		for _, r := range value {
+			lower, upper := unicode.ToLower(r), unicode.ToUpper(r)
+			if lower == upper {
+				b.WriteString(regexp.QuoteMeta(string(r)))
+				continue
+			}
+			b.WriteString("[" + string(lower) + string(upper) + "]")
+		}
+		alternatives[i] = b.String()
+	}
+	return "^(" + strings.Join(alternatives, "|") + ")$"
+}
+
+// structSchema 返回结构体的 JSON Schema，属性名取自 mapstructure 标签
+func structSchema(path string, t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("mapstructure"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		properties[name] = typeSchema(childPath(path, name), t.Field(i).Type)
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
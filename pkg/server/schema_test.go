@@ -0,0 +1,63 @@
+package server
+
+import (
+	"regexp"
+	"testing"
+)
+
+// schemaAt 按属性名逐级查找子 Schema，数组进入 items
+func schemaAt(t *testing.T, schema map[string]interface{}, names ...string) map[string]interface{} {
+	t.Helper()
+	for _, name := range names {
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			schema = items
+		}
+		properties, ok := schema["properties"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("%s 没有 properties", name)
+		}
+		if schema, ok = properties[name].(map[string]interface{}); !ok {
+			t.Fatalf("缺少属性 %s", name)
+		}
+	}
+	return schema
+}
+
+func TestSchemaRelabelActionCaseInsensitive(t *testing.T) {
+	action := schemaAt(t, ConfigSchema(nil), "metric_relabel_configs", "action")
+
+	anyOf, ok := action["anyOf"].([]interface{})
+	if !ok || len(anyOf) != 2 {
+		t.Fatalf("action 应当是 enum 和 pattern 的 anyOf: %v", action)
+	}
+	pattern := regexp.MustCompile(anyOf[1].(map[string]interface{})["pattern"].(string))
+	for _, value := range []string{"replace", "Replace", "LABELDROP", "hashMod"} {
+		if !pattern.MatchString(value) {
+			t.Errorf("action %q 应当有效", value)
+		}
+	}
+	for _, value := range []string{"", "replaced", "keep drop"} {
+		if pattern.MatchString(value) {
+			t.Errorf("action %q 应当无效", value)
+		}
+	}
+}
+
+func TestSchemaDuration(t *testing.T) {
+	timeout := schemaAt(t, ConfigSchema(nil), "timeout")
+	if timeout["minimum"] != 0 {
+		t.Errorf("时长的整数形式不能为负数: %v", timeout)
+	}
+
+	pattern := regexp.MustCompile(timeout["pattern"].(string))
+	for _, value := range []string{"0", "10s", "1m30s", "1.5h", "250ms"} {
+		if !pattern.MatchString(value) {
+			t.Errorf("时长 %q 应当有效", value)
+		}
+	}
+	for _, value := range []string{"-5s", "10", "s", "10 s"} {
+		if pattern.MatchString(value) {
+			t.Errorf("时长 %q 应当无效", value)
+		}
+	}
+}
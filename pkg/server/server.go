@@ -1,30 +1,84 @@
 package server
 
 import (
+	"fmt"
 	"net/http"
+	"os"
+	"sync"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
 )
 
 type Server struct {
-	addr   string
-	engine *gin.Engine
+	addr     string
+	engine   *gin.Engine
+	registry *prometheus.Registry // 暴露在 /metrics 上的指标注册表
+	reload   ReloadFunc           // 重新加载配置，为空时不提供 /-/reload
+
+	// 以下字段在重新加载配置时替换
+	mu        sync.RWMutex
+	probe     ProbeFunc         // 为 /probe 创建收集器，为空时不提供 /probe
+	drift     DriftFunc         // 生成配置漂移报告，为空时不提供 /drift
+	transform GathererTransform // 暴露前对收集结果的变换，为空时原样暴露
+}
+
+// GathererTransform 包装 Gatherer，在暴露前修改收集结果（例如重新标记）
+type GathererTransform func(prometheus.Gatherer) prometheus.Gatherer
+
+// SetTransform 设置 /metrics 和 /probe 暴露前的变换，可以在运行时替换
+func (s *Server) SetTransform(transform GathererTransform) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transform = transform
+}
+
+// gatherer 返回应用了当前变换的 Gatherer
+func (s *Server) gatherer(g prometheus.Gatherer) prometheus.Gatherer {
+	s.mu.RLock()
+	transform := s.transform
+	s.mu.RUnlock()
+
+	if transform == nil {
+		return g
+	}
+	return transform(g)
 }
 
-func New(addr string) *Server {
+func New(addr string, registry *prometheus.Registry) *Server {
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.New()
 	engine.Use(gin.Recovery())
 
 	return &Server{
-		addr:   addr,
-		engine: engine,
+		addr:     addr,
+		engine:   engine,
+		registry: registry,
 	}
 }
 
 func (s *Server) SetupRoutes() {
-	s.engine.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	// 每次请求时取当前的变换，重新加载配置后立即生效
+	gatherer := prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		return s.gatherer(s.registry).Gather()
+	})
+	handler := promhttp.InstrumentMetricHandler(s.registry, exposeHandler(gatherer))
+	s.engine.GET("/metrics", gin.WrapH(handler))
+
+	s.mu.RLock()
+	probe, drift := s.probe, s.drift
+	s.mu.RUnlock()
+	if probe != nil {
+		s.engine.GET("/probe", s.handleProbe)
+	}
+	if drift != nil {
+		s.engine.GET("/drift", s.handleDrift)
+	}
+	if s.reload != nil {
+		s.engine.POST("/-/reload", s.handleReload)
+	}
 	s.engine.GET("/", func(c *gin.Context) {
 		c.Redirect(http.StatusMovedPermanently, "/metrics")
 	})
@@ -33,3 +87,10 @@ func (s *Server) SetupRoutes() {
 func (s *Server) Start() error {
 	return s.engine.Run(s.addr)
 }
+
+// errorLogger 将 promhttp 的收集错误输出到标准错误
+type errorLogger struct{}
+
+func (errorLogger) Println(v ...interface{}) {
+	fmt.Fprintln(os.Stderr, v...)
+}
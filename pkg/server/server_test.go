@@ -0,0 +1,39 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// /-/reload 只在设置了 ReloadFunc 时提供
+func TestReloadRoute(t *testing.T) {
+	tests := []struct {
+		name   string
+		reload ReloadFunc
+		status int
+	}{
+		{"未开启", nil, http.StatusNotFound},
+		{"重新加载成功", func() error { return nil }, http.StatusOK},
+		{"重新加载失败", func() error { return errors.New("配置无效") }, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := New(":0", prometheus.NewRegistry())
+			if tt.reload != nil {
+				s.SetReload(tt.reload)
+			}
+			s.SetupRoutes()
+
+			w := httptest.NewRecorder()
+			s.engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/-/reload", nil))
+			if w.Code != tt.status {
+				t.Errorf("POST /-/reload = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
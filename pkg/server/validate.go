@@ -0,0 +1,420 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigError 配置文件中某一项的错误
+type ConfigError struct {
+	Line int    // 所在行号，0 表示无法定位
+	Path string // 配置项路径，例如 endpoints[0].url
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	switch {
+	case e.Line > 0 && e.Path != "":
+		return fmt.Sprintf("第 %d 行 %s: %v", e.Line, e.Path, e.Err)
+	case e.Line > 0:
+		return fmt.Sprintf("第 %d 行: %v", e.Line, e.Err)
+	case e.Path != "":
+		return fmt.Sprintf("%s: %v", e.Path, e.Err)
+	}
+	return e.Err.Error()
+}
+
+// configErrorf 创建指定配置项的错误，行号由 ConfigDocument.Locate 补充
+func configErrorf(path, format string, args ...interface{}) *ConfigError {
+	return &ConfigError{Path: path, Err: fmt.Errorf(format, args...)}
+}
+
+// ConfigDocument 是配置文件的 YAML 语法树，用于定位配置项所在的行以及检查未知的配置项
+type ConfigDocument struct {
+	root  *yaml.Node
+	lines map[string]int // 配置项路径 → 行号
+}
+
+// yamlLineRE 匹配 yaml.v3 错误信息中的行号
+var yamlLineRE = regexp.MustCompile(`line (\d+)`)
+
+// ParseConfigDocument 解析配置文件的 YAML 语法，语法错误返回带行号的 ConfigError
+func ParseConfigDocument(filename string) (*ConfigDocument, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %v", err)
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		configErr := &ConfigError{Err: fmt.Errorf("YAML 语法错误: %v", strings.TrimPrefix(err.Error(), "yaml: "))}
+		if m := yamlLineRE.FindStringSubmatch(err.Error()); m != nil {
+			configErr.Line, _ = strconv.Atoi(m[1])
+		}
+		return nil, configErr
+	}
+
+	doc := &ConfigDocument{root: &root, lines: map[string]int{}}
+	if len(root.Content) > 0 {
+		doc.index("", root.Content[0])
+	}
+	return doc, nil
+}
+
+// index 记录 node 下每个配置项的行号
+func (d *ConfigDocument) index(path string, node *yaml.Node) {
+	node = resolveAlias(node)
+	if path != "" {
+		if _, ok := d.lines[path]; !ok {
+			d.lines[path] = node.Line
+		}
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := childPath(path, node.Content[i].Value)
+			d.lines[key] = node.Content[i].Line
+			d.index(key, node.Content[i+1])
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			d.index(fmt.Sprintf("%s[%d]", path, i), item)
+		}
+	}
+}
+
+// Line 返回配置项所在的行号，配置项不存在时返回最近的上级配置项的行号
+func (d *ConfigDocument) Line(path string) int {
+	for path != "" {
+		if line, ok := d.lines[path]; ok {
+			return line
+		}
+		if i := strings.LastIndexAny(path, ".["); i >= 0 {
+			path = path[:i]
+		} else {
+			path = ""
+		}
+	}
+	return 0
+}
+
+// Locate 为没有行号的错误补充行号，并按行号排序
+func (d *ConfigDocument) Locate(errs []*ConfigError) {
+	for _, err := range errs {
+		if err.Line == 0 {
+			err.Line = d.Line(err.Path)
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+}
+
+// UnknownKeys 返回 LogstashConfig 中不存在的配置项，与 viper 一样按小写匹配键名
+// labels、modules 等映射的键含有大写字母时同样报告，因为 viper 会把它们转为小写
+func (d *ConfigDocument) UnknownKeys() []*ConfigError {
+	if len(d.root.Content) == 0 {
+		return nil
+	}
+	var errs []*ConfigError
+	unknownKeys("", d.root.Content[0], reflect.TypeOf(LogstashConfig{}), &errs)
+	return errs
+}
+
+// unknownKeys 按 t 的结构检查 node 中的键，类型不匹配的取值留给解码时报告
+func unknownKeys(path string, node *yaml.Node, t reflect.Type, errs *[]*ConfigError) {
+	node = resolveAlias(node)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct && node.Kind == yaml.MappingNode:
+		fields := map[string]reflect.Type{}
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("mapstructure"), ",")[0]
+			if name != "" && name != "-" {
+				fields[name] = t.Field(i).Type
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Value == "<<" {
+				continue
+			}
+			field, ok := fields[strings.ToLower(key.Value)]
+			if !ok {
+				*errs = append(*errs, &ConfigError{
+					Line: key.Line,
+					Path: childPath(path, key.Value),
+					Err:  fmt.Errorf("未知的配置项 %s", key.Value),
+				})
+				continue
+			}
+			unknownKeys(childPath(path, key.Value), node.Content[i+1], field, errs)
+		}
+
+	case t.Kind() == reflect.Map && node.Kind == yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			// viper 会把键转为小写，labels: {Env: x} 实际得到的是 env
+			if lower := strings.ToLower(key.Value); lower != key.Value {
+				*errs = append(*errs, &ConfigError{
+					Line: key.Line,
+					Path: childPath(path, key.Value),
+					Err:  fmt.Errorf("键 %s 含有大写字母，加载时会被转为小写 %s", key.Value, lower),
+				})
+			}
+			unknownKeys(childPath(path, key.Value), node.Content[i+1], t.Elem(), errs)
+		}
+
+	case t.Kind() == reflect.Slice && node.Kind == yaml.SequenceNode:
+		for i, item := range node.Content {
+			unknownKeys(fmt.Sprintf("%s[%d]", path, i), item, t.Elem(), errs)
+		}
+	}
+}
+
+// resolveAlias 返回别名指向的节点
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// childPath 返回 path 下名为 key 的配置项路径
+func childPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// decodeErrorRE 匹配 mapstructure 错误信息中出错的配置项，例如 'retry.max_attempts'
+var decodeErrorRE = regexp.MustCompile(`'([^']+)'`)
+
+// DecodeErrors 将 LoadConfig 返回的解码错误拆分为每个配置项的错误
+func DecodeErrors(err error) []*ConfigError {
+	var errs []*ConfigError
+	for _, line := range strings.Split(err.Error(), "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "*"))
+		m := decodeErrorRE.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		errs = append(errs, &ConfigError{Path: m[1], Err: fmt.Errorf("%s", line)})
+	}
+	if len(errs) == 0 {
+		errs = append(errs, &ConfigError{Err: err})
+	}
+	return errs
+}
+
+// labelNameRE 是 Prometheus 标签名的合法格式
+var labelNameRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ValidateConfig 检查配置项的取值：监听地址、时长、端点地址、标签名、正则表达式和 TLS 文件
+// 依赖其他包的检查（重新标记规则、命名配置、证书内容）由调用方完成
+func ValidateConfig(config *LogstashConfig) []*ConfigError {
+	errs := ValidateDurations(config)
+
+	if addr := config.Web.ListenAddress; addr != "" {
+		if _, port, err := net.SplitHostPort(addr); err != nil {
+			errs = append(errs, configErrorf("web.listen_address", "监听地址 %q 不合法: %v", addr, err))
+		} else if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+			errs = append(errs, configErrorf("web.listen_address", "监听端口 %q 不合法", port))
+		}
+	}
+
+	if !validLabelName(config.InstanceLabel) {
+		errs = append(errs, configErrorf("instance_label", "标签名 %q 不合法", config.InstanceLabel))
+	}
+	errs = append(errs, validateLabels("labels", config.Labels)...)
+
+	errs = append(errs, validateTLSFiles("tls", config.TLS)...)
+
+	// 地址和实例标识（名称或 host:port）都不能重复，否则指标无法区分
+	seen := map[string]int{}
+	instances := map[string]int{}
+	for i, ep := range config.Endpoints {
+		path := fmt.Sprintf("endpoints[%d]", i)
+		endpoint := strings.TrimSpace(ep.URL)
+		if err := validateEndpointURL(endpoint); err != nil {
+			errs = append(errs, &ConfigError{Path: path + ".url", Err: err})
+		} else if j, ok := seen[endpoint]; ok {
+			errs = append(errs, configErrorf(path+".url", "与 endpoints[%d] 重复", j))
+		} else {
+			seen[endpoint] = i
+			instance, field := strings.TrimSpace(ep.Name), ".name"
+			if instance == "" {
+				u, _ := url.Parse(endpoint)
+				instance, field = u.Host, ".url"
+			}
+			if j, ok := instances[instance]; ok {
+				errs = append(errs, configErrorf(path+field, "实例标识 %q 与 endpoints[%d] 重复", instance, j))
+			} else {
+				instances[instance] = i
+			}
+		}
+		errs = append(errs, validateLabels(path+".labels", ep.Labels)...)
+		errs = append(errs, validateFilters(path+".filters", ep.Filters)...)
+		errs = append(errs, validateTLSFiles(path+".tls", ep.TLS)...)
+	}
+
+	for name := range config.Clusters {
+		if !clusterReferenced(config.Endpoints, name) {
+			errs = append(errs, configErrorf("clusters."+name, "没有端点属于集群 %s", name))
+		}
+	}
+
+	for name, module := range config.Modules {
+		path := "modules." + name
+		errs = append(errs, validateFilters(path+".filters", module.Filters)...)
+		errs = append(errs, validateTLSFiles(path+".tls", module.TLS)...)
+	}
+
+	return errs
+}
+
+// ValidateDurations 检查所有时长配置项不为负数，与 JSON Schema 的 minimum 一致
+// 启动和重新加载时同样执行，负数的超时、间隔和退避时间没有意义
+func ValidateDurations(config *LogstashConfig) []*ConfigError {
+	var errs []*ConfigError
+	negativeDurations("", reflect.ValueOf(*config), &errs)
+	return errs
+}
+
+// negativeDurations 按 mapstructure 标签遍历 v，记录取值为负数的时长
+func negativeDurations(path string, v reflect.Value, errs *[]*ConfigError) {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		if v.Int() < 0 {
+			*errs = append(*errs, configErrorf(path, "时长 %s 不能为负数", time.Duration(v.Int())))
+		}
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			name := strings.Split(v.Type().Field(i).Tag.Get("mapstructure"), ",")[0]
+			if name != "" && name != "-" {
+				negativeDurations(childPath(path, name), v.Field(i), errs)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			negativeDurations(fmt.Sprintf("%s[%d]", path, i), v.Index(i), errs)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			negativeDurations(childPath(path, key.String()), v.MapIndex(key), errs)
+		}
+	}
+}
+
+// validateEndpointURL 检查端点地址：必须是带主机的 http 或 https 地址
+func validateEndpointURL(endpoint string) error {
+	if endpoint == "" {
+		return fmt.Errorf("端点地址不能为空")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("端点地址不合法: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("端点地址 %q 必须以 http:// 或 https:// 开头", endpoint)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("端点地址 %q 缺少主机", endpoint)
+	}
+	return nil
+}
+
+// validateLabels 检查常量标签名
+func validateLabels(path string, labels map[string]string) []*ConfigError {
+	var errs []*ConfigError
+	for name := range labels {
+		if !validLabelName(name) {
+			errs = append(errs, configErrorf(childPath(path, name), "标签名 %q 不合法", name))
+		}
+	}
+	return errs
+}
+
+// validateFilters 检查过滤规则中的正则表达式，与收集器一样按完整匹配编译
+func validateFilters(path string, filters FiltersConfig) []*ConfigError {
+	var errs []*ConfigError
+	matches := []struct {
+		field string
+		match MatchConfig
+	}{
+		{"pipelines", filters.Pipelines},
+		{"plugin_types", filters.PluginTypes},
+		{"plugin_names", filters.PluginNames},
+		{"plugin_ids", filters.PluginIDs},
+	}
+	for _, m := range matches {
+		for i, expr := range []string{m.match.Include, m.match.Exclude} {
+			if expr == "" {
+				continue
+			}
+			if _, err := regexp.Compile("^(?:" + expr + ")$"); err != nil {
+				field := [...]string{"include", "exclude"}[i]
+				errs = append(errs, configErrorf(path+"."+m.field+"."+field, "正则表达式无效: %v", err))
+			}
+		}
+	}
+	return errs
+}
+
+// validateTLSFiles 检查 TLS 文件是否存在，证书内容在创建客户端时校验
+func validateTLSFiles(path string, tls TLSConfig) []*ConfigError {
+	var errs []*ConfigError
+	files := []struct {
+		field string
+		file  string
+	}{
+		{"ca_file", tls.CAFile},
+		{"cert_file", tls.CertFile},
+		{"key_file", tls.KeyFile},
+	}
+	for _, f := range files {
+		if f.file == "" {
+			continue
+		}
+		if _, err := os.Stat(f.file); err != nil {
+			errs = append(errs, configErrorf(path+"."+f.field, "无法读取文件: %v", err))
+		}
+	}
+	if (tls.CertFile == "") != (tls.KeyFile == "") {
+		errs = append(errs, configErrorf(path, "cert_file 和 key_file 必须同时设置"))
+	}
+	return errs
+}
+
+// validLabelName 判断是否是合法的标签名，__ 开头的标签名保留给内部使用
+func validLabelName(name string) bool {
+	return labelNameRE.MatchString(name) && !strings.HasPrefix(name, "__")
+}
+
+// clusterReferenced 判断是否有端点属于集群 name，viper 会把 clusters 的键转为小写
+func clusterReferenced(endpoints []EndpointConfig, name string) bool {
+	for _, ep := range endpoints {
+		if strings.EqualFold(ep.Cluster, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestConfig 把 content 写入临时配置文件并返回路径
+func writeTestConfig(t *testing.T, content string) string {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(filename, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestUnknownKeys(t *testing.T) {
+	filename := writeTestConfig(t, `timeout: 5s
+retyr:
+  max_attempts: 2
+labels:
+  Env: prod
+  team: logs
+endpoints:
+  - url: http://localhost:9600
+    labels:
+      Zone: a
+`)
+	doc, err := ParseConfigDocument(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errs := doc.UnknownKeys()
+	doc.Locate(errs)
+
+	want := map[string]int{
+		"retyr":                    2,
+		"labels.Env":               5,
+		"endpoints[0].labels.Zone": 10,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("errs = %v, want %d 个错误", errs, len(want))
+	}
+	for _, err := range errs {
+		line, ok := want[err.Path]
+		if !ok {
+			t.Errorf("意外的错误 %v", err)
+			continue
+		}
+		if err.Line != line {
+			t.Errorf("%s 的行号 = %d, want %d", err.Path, err.Line, line)
+		}
+	}
+}
+
+func TestLoadConfigFileIgnoresEnv(t *testing.T) {
+	filename := writeTestConfig(t, "timeout: 5s\n")
+	t.Setenv(EnvPrefix+"_TIMEOUT", "7s")
+
+	config, err := LoadConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Timeout != 7*time.Second {
+		t.Errorf("LoadConfig: timeout = %s, want 7s（环境变量优先）", config.Timeout)
+	}
+
+	config, err = LoadConfigFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Timeout != 5*time.Second {
+		t.Errorf("LoadConfigFile: timeout = %s, want 5s（忽略环境变量）", config.Timeout)
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		path    string // 期望出错的配置项，为空表示配置有效
+	}{
+		{"有效配置", "endpoints:\n  - http://localhost:9600\n", ""},
+		{"无效的监听地址", "web:\n  listen_address: localhost\n", "web.listen_address"},
+		{"不支持的协议", "endpoints:\n  - ftp://localhost:9600\n", "endpoints[0].url"},
+		{"重复的端点", "endpoints:\n  - http://a:9600\n  - http://a:9600\n", "endpoints[1].url"},
+		{"无效的标签名", "labels:\n  0bad: x\n", "labels.0bad"},
+		{"无效的正则", "endpoints:\n  - url: http://a:9600\n    filters:\n      pipelines:\n        include: '('\n", "endpoints[0].filters.pipelines"},
+		{"证书和私钥必须同时设置", "tls:\n  cert_file: " + os.Args[0] + "\n", "tls"},
+		{"没有端点的集群", "endpoints:\n  - http://a:9600\nclusters:\n  ingest:\n    min_healthy: 1\n", "clusters.ingest"},
+		{"负数的全局超时", "timeout: -1s\n", "timeout"},
+		{"负数的端点超时", "endpoints:\n  - url: http://a:9600\n    timeout: -1s\n", "endpoints[0].timeout"},
+		{"负数的轮询间隔", "poll:\n  interval: -5s\n", "poll.interval"},
+		{"负数的快照年龄", "poll:\n  max_age: -1m\n", "poll.max_age"},
+		{"负数的最小刷新间隔", "min_refresh_interval: -1s\n", "min_refresh_interval"},
+		{"负数的节点信息缓存", "node_info_ttl: -1m\n", "node_info_ttl"},
+		{"负数的退避时间", "retry:\n  initial_backoff: -200ms\n", "retry.initial_backoff"},
+		{"负数的熔断时间", "circuit_breaker:\n  open_timeout: -30s\n", "circuit_breaker.open_timeout"},
+		{"负数的模块超时", "modules:\n  fast:\n    timeout: -2s\n", "modules.fast.timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := LoadConfigFile(writeTestConfig(t, tt.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs := ValidateConfig(config)
+			if tt.path == "" {
+				if len(errs) != 0 {
+					t.Errorf("errs = %v, want 无错误", errs)
+				}
+				return
+			}
+			for _, err := range errs {
+				if err.Path == tt.path || strings.HasPrefix(err.Path, tt.path+".") {
+					return
+				}
+			}
+			t.Errorf("errs = %v, want %s 的错误", errs, tt.path)
+		})
+	}
+}